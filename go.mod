@@ -4,6 +4,9 @@ go 1.14
 
 require (
 	github.com/google/uuid v1.1.1
+	github.com/gorilla/websocket v1.4.2
 	github.com/lukehollenback/packet-server v0.0.0-20200423010303-139b80f7fa1b
 	github.com/mitchellh/mapstructure v1.2.2
+	go.etcd.io/bbolt v1.3.5
+	golang.org/x/text v0.3.6
 )
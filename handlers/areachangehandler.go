@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/services/msghandlerservice"
+)
+
+func init() {
+	msghandlerservice.Instance().RegisterTypedMsgHandler(true, handleAreaChange)
+}
+
+//
+// handleAreaChange is intended to be registered with the Message Handler Service to be used to
+// actually processes a recieved message.
+//
+func handleAreaChange(
+	client *models.Client,
+	rcvMsg *msgmodels.Msg,
+	rcvMsgData *msgmodels.AreaChange,
+) error {
+	//
+	// Move the client's player character. The Game Server Service takes care of validating the move,
+	// deciding where in the area it ends up, and telling everybody involved about it.
+	//
+	return gameserverservice.Instance().ChangeArea(client, rcvMsgData.AreaID)
+}
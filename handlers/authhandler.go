@@ -2,39 +2,97 @@ package handlers
 
 import (
 	"fmt"
-	"reflect"
+	"log"
 
 	"github.com/lukehollenback/arcane-server/models"
 	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/authservice"
 	"github.com/lukehollenback/arcane-server/services/gameserverservice"
 	"github.com/lukehollenback/arcane-server/services/msghandlerservice"
 	"github.com/lukehollenback/arcane-server/services/playerinfoservice"
-	"github.com/mitchellh/mapstructure"
 )
 
 func init() {
-	msghandlerservice.Instance().RegisterMsgHandler(
-		reflect.TypeOf(new(msgmodels.Auth)).Elem().Name(),
-		false,
-		handleAuth,
-	)
+	msghandlerservice.Instance().RegisterTypedMsgHandler(false, handleAuth)
 }
 
 //
 // handleAuth is intended to be registered with the Message Handler Service to be used to
 // actually processes a recieved message.
 //
-func handleAuth(client *models.Client, rcvMsg *msgmodels.Msg) error {
+func handleAuth(client *models.Client, rcvMsg *msgmodels.Msg, rcvMsgData *msgmodels.Auth) error {
 	//
-	// Deserialize the data payload in the message.
+	// Make sure that the client has told us which version of the protocol it speaks first. Clients
+	// that predate the protocol handshake would otherwise fail in confusing ways later on.
 	//
-	rcvMsgData := &msgmodels.Auth{}
+	if !client.Negotiated() {
+		gameserverservice.Instance().Disconnect(
+			client,
+			"A \"Hello\" message must be sent before authenticating. Please update your game.",
+		)
 
-	mapstructure.Decode(rcvMsg.Data, rcvMsgData)
+		return nil
+	}
 
 	//
-	// Set the client's "authenticated" sentinel.
+	// Make sure the client is not attempting to authenticate a second time (e.g. to swap identities
+	// mid-session).
+	//
+	if client.Authed() {
+		authFailData := &msgmodels.AuthFail{
+			Reason: "Already authenticated.",
+		}
+		authFailMsg := msgmodels.CreateReply(rcvMsg, authFailData)
+
+		gameserverservice.Instance().SendMessage(client, authFailMsg)
+
+		return nil
+	}
+
 	//
+	// Verify the provided token. If it is no good, tell the client why and – if they have run out of
+	// attempts – disconnect them.
+	//
+	claims, verifyErr := authservice.Instance().Verify(rcvMsgData.Token)
+	if verifyErr != nil {
+		fails := client.IncAuthFailures()
+		remaining := authservice.Instance().MaxFailures() - fails
+
+		log.Printf(
+			"%sFailed to verify authentication token. (Attempt: %d) (Error: %s)",
+			client.LogPrefix(), fails, verifyErr,
+		)
+
+		if remaining <= 0 {
+			gameserverservice.Instance().Disconnect(client, "Too many failed authentication attempts.")
+
+			return nil
+		}
+
+		authFailData := &msgmodels.AuthFail{
+			Reason:            fmt.Sprintf("Authentication failed. (Reason: %s)", verifyErr),
+			AttemptsRemaining: remaining,
+		}
+		authFailMsg := msgmodels.CreateReply(rcvMsg, authFailData)
+
+		gameserverservice.Instance().SendMessage(client, authFailMsg)
+
+		return nil
+	}
+
+	//
+	// Record the login (along with the roles that the token grants) against the player's persisted
+	// record (creating it if this is their first time), then set the client's verified identity and
+	// "authenticated" sentinel.
+	//
+	player, err := playerinfoservice.Instance().RecordLogin(
+		claims.PlayerID, claims.Username, claims.Roles,
+	)
+	if err != nil {
+		return err
+	}
+
+	client.SetPlayerID(claims.PlayerID)
 	client.SetAuthed(true)
 
 	//
@@ -43,10 +101,18 @@ func handleAuth(client *models.Client, rcvMsg *msgmodels.Msg) error {
 	authData := &msgmodels.Auth{
 		Token: rcvMsgData.Token,
 	}
-	authMsg := msgmodels.CreateMsg(authData)
+	authMsg := msgmodels.CreateReply(rcvMsg, authData)
 
 	gameserverservice.Instance().SendMessage(client, authMsg)
 
+	//
+	// Put the player character back where the player left off last time (if they have been here
+	// before).
+	//
+	if len(player.LastAreaID) > 0 {
+		gameserverservice.Instance().RestoreLocation(client, player.LastAreaID, player.LastX, player.LastY)
+	}
+
 	//
 	// Generate and send a welcome chat message.
 	//
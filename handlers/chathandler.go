@@ -1,56 +1,173 @@
 package handlers
 
 import (
-	"reflect"
+	"fmt"
+	"log"
+	"strings"
 
 	"github.com/lukehollenback/arcane-server/models"
 	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/chatfilterservice"
+	"github.com/lukehollenback/arcane-server/services/cmdservice"
 	"github.com/lukehollenback/arcane-server/services/gameserverservice"
 	"github.com/lukehollenback/arcane-server/services/msghandlerservice"
 	"github.com/lukehollenback/arcane-server/services/playerinfoservice"
 	"github.com/lukehollenback/arcane-server/util"
-	"github.com/mitchellh/mapstructure"
 )
 
+//
+// chatColorRoles maps each chat color that players may send messages in to the role required to do
+// so (empty if anybody may). Administrators may use every color.
+//
+var chatColorRoles = map[string]string{
+	msgmodels.ChatColDef:  "",
+	msgmodels.ChatColMod:  playerinfoservice.RoleModerator,
+	msgmodels.ChatColSvr:  playerinfoservice.RoleAdmin,
+	msgmodels.ChatColGame: playerinfoservice.RoleAdmin,
+	msgmodels.ChatColSys:  playerinfoservice.RoleAdmin,
+}
+
 func init() {
-	msghandlerservice.Instance().RegisterMsgHandler(
-		reflect.TypeOf(new(msgmodels.Chat)).Elem().Name(),
-		true,
-		handleChat,
-	)
+	msghandlerservice.Instance().RegisterTypedMsgHandler(true, handleChat)
+
+	cmdservice.Instance().RegisterCommand(&cmdservice.Command{
+		Name:    "join",
+		Usage:   "<channel>",
+		Summary: "Starts receiving the messages sent to an opt-in chat channel.",
+		Args:    cmdservice.Args(1, 1),
+		Run:     runJoin,
+	})
+
+	cmdservice.Instance().RegisterCommand(&cmdservice.Command{
+		Name:    "leave",
+		Usage:   "<channel>",
+		Summary: "Stops receiving the messages sent to an opt-in chat channel.",
+		Args:    cmdservice.Args(1, 1),
+		Run:     runLeave,
+	})
+
+	cmdservice.Instance().RegisterCommand(&cmdservice.Command{
+		Name:    "channels",
+		Summary: "Lists the chat channels available to you.",
+		Run:     runChannels,
+	})
 }
 
 //
 // handle is intended to be registered with the Message Handler Service to be used to actually
 // processes a recieved message.
 //
-func handleChat(client *models.Client, rcvMsg *msgmodels.Msg) error {
+func handleChat(client *models.Client, rcvMsg *msgmodels.Msg, rcvMsgData *msgmodels.Chat) error {
 	//
-	// Deserialize the data payload in the message.
+	// Hand commands (e.g. "/help") off to the Command Service rather than relaying them to anybody.
 	//
-	rcvMsgData := new(msgmodels.Chat)
+	if cmdservice.IsCommand(rcvMsgData.Content) {
+		cmdservice.Instance().Execute(client, rcvMsgData.Content)
 
-	mapstructure.Decode(rcvMsg.Data, rcvMsgData)
+		return nil
+	}
 
 	//
-	// Generate a "ChatMsg"-type message and send it to all connected players. To prevent the ability
-	// for any players to be weird and spoof their username, said field is always looked up – even if
-	// it was provided. If a color was optionally provided, it will be used.
+	// Sanitize the content (e.g. stripping illegal characters and filtering out bad language). If it
+	// is not fit to be sent at all, tell the sender why.
+	//
+	content, err := chatfilterservice.Instance().Sanitize(cmdservice.Unescape(rcvMsgData.Content))
+	if err != nil {
+		cmdservice.Reply(client, fmt.Sprintf("Your message was not sent. (Reason: %s)", err))
+
+		return nil
+	}
+
 	//
-	// TODO: Validate everything – content (for excessive whitespace, illegal characters, and so on),
-	//  color (to be allowed according to the senders permissions), and so on.
+	// Generate a "ChatMsg"-type message and send it to everybody in the chat channel that it targets
+	// (area chat by default). To prevent the ability for any players to be weird and spoof their
+	// username, said field is always looked up – even if it was provided. If a color was optionally
+	// provided, it will be used as long as the sender is allowed to use it.
 	//
 	sndMsgAuthor := playerinfoservice.Instance().GetUsername(client.PlayerID())
-	sndMsgColor := util.GetStrVal(rcvMsgData.Color, msgmodels.ChatColDef)
+	sndMsgColor := chatColor(client, util.GetStrVal(rcvMsgData.Color, msgmodels.ChatColDef))
 	sndMsgData := &msgmodels.Chat{
 		Author:  sndMsgAuthor,
-		Content: rcvMsgData.Content,
+		Content: content,
 		Color:   sndMsgColor,
+		Channel: rcvMsgData.Channel,
+	}
+
+	if err := gameserverservice.Instance().SendChatMessage(client, sndMsgData); err != nil {
+		cmdservice.Reply(client, fmt.Sprintf(
+			"Could not send to chat channel \"%s\". (Reason: %s)",
+			util.GetStrVal(rcvMsgData.Channel, msgmodels.ChatChanArea), err,
+		))
+	}
+
+	return nil
+}
+
+//
+// chatColor checks that the provided client is allowed to send chat messages in the requested
+// color. If it is not (e.g. a regular player attempting to impersonate a moderator), the default
+// color is returned instead and the attempt is logged as suspicious.
+//
+func chatColor(client *models.Client, color string) string {
+	role, prs := chatColorRoles[color]
+	if prs && playerinfoservice.Instance().Authorized(client.PlayerID(), role) {
+		return color
 	}
 
-	sndMsg := msgmodels.CreateMsg(sndMsgData)
+	log.Printf(
+		"%sSuspicious chat color downgraded. (Player: %s) (Color: %s)",
+		client.LogPrefix(), client.PlayerID(), color,
+	)
 
-	gameserverservice.Instance().SendAllMessage(sndMsg, nil)
+	return msgmodels.ChatColDef
+}
+
+//
+// runJoin implements the "/join" chat command.
+//
+func runJoin(client *models.Client, args []string) error {
+	if err := gameserverservice.Instance().JoinChatChannel(client, args[0]); err != nil {
+		return fmt.Errorf("Could not join chat channel \"%s\". (Reason: %s)", args[0], err)
+	}
+
+	cmdservice.Reply(client, fmt.Sprintf("Joined chat channel \"%s\".", strings.ToLower(args[0])))
+
+	return nil
+}
+
+//
+// runLeave implements the "/leave" chat command.
+//
+func runLeave(client *models.Client, args []string) error {
+	if err := gameserverservice.Instance().LeaveChatChannel(client, args[0]); err != nil {
+		return fmt.Errorf("Could not leave chat channel \"%s\". (Reason: %s)", args[0], err)
+	}
+
+	cmdservice.Reply(client, fmt.Sprintf("Left chat channel \"%s\".", strings.ToLower(args[0])))
+
+	return nil
+}
+
+//
+// runChannels implements the "/channels" chat command.
+//
+func runChannels(client *models.Client, args []string) error {
+	cmdservice.Reply(client, "Available chat channels:")
+
+	for _, channel := range gameserverservice.Instance().ChatChannels(client) {
+		status := "not joined"
+
+		switch {
+		case channel.Scope == models.ChatScopeArea, channel.Scope == models.ChatScopeGlobal:
+			status = "always joined"
+		case gameserverservice.Instance().InChatChannel(client, channel.Name):
+			status = "joined"
+		case channel.Scope == models.ChatScopeParty:
+			status = "not in a party"
+		}
+
+		cmdservice.Reply(client, fmt.Sprintf("%s (%s)", channel.Name, status))
+	}
 
 	return nil
 }
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/services/msghandlerservice"
+)
+
+func init() {
+	msghandlerservice.Instance().RegisterTypedMsgHandler(false, handleCodecSelect)
+}
+
+//
+// handleCodecSelect is intended to be registered with the Message Handler Service to be used to
+// actually processes a recieved message.
+//
+func handleCodecSelect(
+	client *models.Client,
+	rcvMsg *msgmodels.Msg,
+	rcvMsgData *msgmodels.CodecSelect,
+) error {
+	//
+	// If the requested codec is not one that we know, or is the binary codec without the client
+	// having said that it supports it during the protocol handshake, stick with the current one.
+	//
+	codec, prs := msgmodels.LookupCodec(rcvMsgData.Name)
+	if !prs {
+		log.Printf("%sRequested unknown codec \"%s\".", client.LogPrefix(), rcvMsgData.Name)
+
+		codec = client.Codec()
+	} else if codec.Name() == msgmodels.CodecBinary && !client.HasCapability(msgmodels.CapBinaryCodec) {
+		log.Printf("%sRequested the binary codec without negotiating it.", client.LogPrefix())
+
+		codec = client.Codec()
+	}
+
+	//
+	// Tell the client which codec will be used from now on. This is sent in the old wire format so
+	// that the client is able to read it, and only then is the switch actually made.
+	//
+	sndMsgData := &msgmodels.CodecSelect{
+		Name: codec.Name(),
+	}
+	sndMsg := msgmodels.CreateReply(rcvMsg, sndMsgData)
+
+	gameserverservice.Instance().SendMessage(client, sndMsg)
+
+	client.SetCodec(codec)
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/services/msghandlerservice"
+)
+
+func init() {
+	msghandlerservice.Instance().RegisterTypedMsgHandler(false, handleHello)
+}
+
+//
+// handleHello is intended to be registered with the Message Handler Service to be used to
+// actually processes a recieved message.
+//
+func handleHello(client *models.Client, rcvMsg *msgmodels.Msg, rcvMsgData *msgmodels.Hello) error {
+	if client.Negotiated() {
+		return fmt.Errorf("the protocol handshake has already been performed")
+	}
+
+	//
+	// Refuse to talk to clients that speak a version of the protocol that we do not.
+	//
+	if rcvMsgData.ProtocolVersion < msgmodels.MinProtocolVersion ||
+		rcvMsgData.ProtocolVersion > msgmodels.ProtocolVersion {
+		log.Printf(
+			"%sRefusing incompatible protocol version %d.",
+			client.LogPrefix(), rcvMsgData.ProtocolVersion,
+		)
+
+		gameserverservice.Instance().Disconnect(client, fmt.Sprintf(
+			"Incompatible protocol version %d. The server supports versions %d through %d.",
+			rcvMsgData.ProtocolVersion,
+			msgmodels.MinProtocolVersion,
+			msgmodels.ProtocolVersion,
+		))
+
+		return nil
+	}
+
+	//
+	// Settle on the optional capabilities that both sides support.
+	//
+	supported := serverCapabilities()
+	negotiated := make([]string, 0, len(rcvMsgData.Capabilities))
+
+	for _, capability := range rcvMsgData.Capabilities {
+		if supported[capability] {
+			negotiated = append(negotiated, capability)
+		}
+	}
+
+	client.SetProtocol(rcvMsgData.ProtocolVersion, negotiated)
+
+	//
+	// Tell the client what was negotiated.
+	//
+	sndMsgData := &msgmodels.Hello{
+		ProtocolVersion: msgmodels.ProtocolVersion,
+		Capabilities:    negotiated,
+	}
+	sndMsg := msgmodels.CreateReply(rcvMsg, sndMsgData)
+
+	gameserverservice.Instance().SendMessage(client, sndMsg)
+
+	return nil
+}
+
+//
+// serverCapabilities returns the set of optional capabilities that the server currently supports.
+//
+func serverCapabilities() map[string]bool {
+	return map[string]bool{
+		msgmodels.CapBinaryCodec: true,
+		msgmodels.CapUDP:         gameserverservice.Instance().UDPEnabled(),
+	}
+}
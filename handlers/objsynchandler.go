@@ -1,45 +1,74 @@
 package handlers
 
 import (
+  "fmt"
+  "math"
+
   "github.com/lukehollenback/arcane-server/models"
   "github.com/lukehollenback/arcane-server/models/msgmodels"
+  "github.com/lukehollenback/arcane-server/services/anticheatservice"
   "github.com/lukehollenback/arcane-server/services/gameserverservice"
   "github.com/lukehollenback/arcane-server/services/msghandlerservice"
-  "github.com/mitchellh/mapstructure"
-  "reflect"
+  "github.com/lukehollenback/arcane-server/services/objschemaservice"
+  "github.com/lukehollenback/arcane-server/services/worldservice"
 )
 
 func init() {
-  msghandlerservice.Instance().RegisterMsgHandler(
-    reflect.TypeOf(new(msgmodels.ObjSync)).Elem().Name(),
-    true,
-    handleObjSync,
-  )
+  msghandlerservice.Instance().RegisterTypedMsgHandler(true, handleObjSync)
+
+  objschemaservice.Instance().RegisterSchema(models.ObjTypePlayer, objschemaservice.Schema{
+    models.SyncVarX:     {Type: objschemaservice.VarInt, Min: math.MinInt32, Max: math.MaxInt32},
+    models.SyncVarY:     {Type: objschemaservice.VarInt, Min: math.MinInt32, Max: math.MaxInt32},
+    models.SyncVarDepth: {Type: objschemaservice.VarInt, Min: math.MinInt32, Max: math.MaxInt32},
+    "sprite":            {Type: objschemaservice.VarString, MaxLen: 64},
+    "facing":            {Type: objschemaservice.VarEnum, Values: []string{"up", "down", "left", "right"}},
+  })
 }
 
 //
 // handle is intended to be registered with the Message Handler Service to be used to actually
 // processes a received message.
 //
-func handleObjSync(client *models.Client, rcvMsg *msgmodels.Msg) error {
+func handleObjSync(client *models.Client, rcvMsg *msgmodels.Msg, data *msgmodels.ObjSync) error {
   //
-  // Deserialize the data payload in the message and perform anti-cheat validation on the
-  // synchronized variable's values.
+  // Strip the synchronized variables down to only those that the object's type actually has, and
+  // make sure they all hold sensible values.
   //
-  data := new(msgmodels.ObjSync)
+  object, prs := gameserverservice.Instance().Object(data.ObjectID)
+  if !prs {
+    return fmt.Errorf("no object with the unique ID \"%s\" exists", data.ObjectID)
+  }
 
-  if err := mapstructure.Decode(rcvMsg.Data, data); err != nil {
+  vars, err := objschemaservice.Instance().Validate(object.Type(), data.Variables)
+  if err != nil {
     return err
   }
 
-  // TODO ~> Perform anti-cheat validation.s
+  if len(vars) == 0 {
+    return nil
+  }
+
+  data.Variables = vars
+
+  //
+  // Perform anti-cheat validation on the synchronized variable's values.
+  //
+  if err := anticheatservice.Instance().ValidateObjSync(client, data); err != nil {
+    return err
+  }
 
   //
-  // Fire off the synchronization message to all other connected clients.
+  // A violation may have gotten the client kicked, in which case its synchronization is dropped.
   //
-  // TODO ~> Localize this to only clients in the relevant area.
+  if client.Closed() {
+    return nil
+  }
+
+  //
+  // Queue the synchronization request up so that it gets applied by the world simulation (and sent
+  // out to all other clients in the sender's area) during its next tick.
   //
-  gameserverservice.Instance().SendAllMessage(rcvMsg, []int{client.TCPClient().ID()})
+  worldservice.Instance().QueueInput(client, data)
 
   return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/services/cmdservice"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/services/playerinfoservice"
+)
+
+func init() {
+	cmdservice.Instance().RegisterCommand(&cmdservice.Command{
+		Name:    "invite",
+		Usage:   "<player>",
+		Summary: "Invites another player to your party, forming one if you are not in one yet.",
+		Args:    cmdservice.Args(1, 1),
+		Run:     runInvite,
+	})
+
+	cmdservice.Instance().RegisterCommand(&cmdservice.Command{
+		Name:    "accept",
+		Usage:   "<player>",
+		Summary: "Joins the party of a player that has invited you to it.",
+		Args:    cmdservice.Args(1, 1),
+		Run:     runAccept,
+	})
+
+	cmdservice.Instance().RegisterCommand(&cmdservice.Command{
+		Name:    "leaveparty",
+		Summary: "Leaves your party.",
+		Run:     runLeaveParty,
+	})
+
+	cmdservice.Instance().RegisterCommand(&cmdservice.Command{
+		Name:    "party",
+		Summary: "Lists the members of your party.",
+		Run:     runParty,
+	})
+}
+
+//
+// runInvite implements the "/invite" chat command.
+//
+func runInvite(client *models.Client, args []string) error {
+	target, err := findOnlinePlayer(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := gameserverservice.Instance().InviteToParty(client, target); err != nil {
+		return fmt.Errorf("Could not invite %s to your party. (Reason: %s)", args[0], err)
+	}
+
+	username := playerinfoservice.Instance().GetUsername(client.PlayerID())
+
+	cmdservice.Reply(target, fmt.Sprintf(
+		"%s has invited you to their party. Type \"%saccept %s\" to join it.",
+		username, cmdservice.Prefix, username,
+	))
+	cmdservice.Reply(client, fmt.Sprintf("Invited %s to your party.", args[0]))
+
+	return nil
+}
+
+//
+// runAccept implements the "/accept" chat command.
+//
+func runAccept(client *models.Client, args []string) error {
+	inviter, err := findOnlinePlayer(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := gameserverservice.Instance().JoinParty(client, inviter); err != nil {
+		return fmt.Errorf("Could not join the party of %s. (Reason: %s)", args[0], err)
+	}
+
+	tellParty(client, fmt.Sprintf(
+		"%s has joined the party.", playerinfoservice.Instance().GetUsername(client.PlayerID()),
+	))
+
+	return nil
+}
+
+//
+// runLeaveParty implements the "/leaveparty" chat command.
+//
+func runLeaveParty(client *models.Client, args []string) error {
+	members := gameserverservice.Instance().PartyMembers(client)
+
+	if err := gameserverservice.Instance().LeaveParty(client); err != nil {
+		return fmt.Errorf("Could not leave your party. (Reason: %s)", err)
+	}
+
+	text := fmt.Sprintf("%s has left the party.", playerinfoservice.Instance().GetUsername(client.PlayerID()))
+
+	for _, member := range members {
+		cmdservice.Reply(member, text)
+	}
+
+	return nil
+}
+
+//
+// runParty implements the "/party" chat command.
+//
+func runParty(client *models.Client, args []string) error {
+	members := gameserverservice.Instance().PartyMembers(client)
+	if members == nil {
+		return fmt.Errorf(
+			"You are not in a party. Type \"%sinvite <player>\" to form one.", cmdservice.Prefix,
+		)
+	}
+
+	usernames := make([]string, 0, len(members))
+
+	for _, member := range members {
+		usernames = append(usernames, playerinfoservice.Instance().GetUsername(member.PlayerID()))
+	}
+
+	sort.Strings(usernames)
+
+	cmdservice.Reply(client, fmt.Sprintf("Party members: %s", strings.Join(usernames, ", ")))
+
+	return nil
+}
+
+//
+// findOnlinePlayer resolves the specified username to the client of the player that holds it. If
+// that can not be done (e.g. because they are offline), an error explaining why (that is suitable
+// for showing to the client that asked) is returned.
+//
+func findOnlinePlayer(username string) (*models.Client, error) {
+	playerID, err := playerinfoservice.Instance().FindPlayerID(username)
+
+	switch err {
+	case nil:
+	case playerinfoservice.ErrUsernameAmbiguous:
+		return nil, fmt.Errorf("More than one player is named \"%s\".", username)
+	default:
+		return nil, fmt.Errorf("There is no player named \"%s\".", username)
+	}
+
+	target, prs := gameserverservice.Instance().FindClientByPlayerID(playerID)
+	if !prs {
+		return nil, fmt.Errorf("%s is not online.", playerinfoservice.Instance().GetUsername(playerID))
+	}
+
+	return target, nil
+}
+
+//
+// tellParty sends the provided system message to every member of the provided client's party.
+//
+func tellParty(client *models.Client, text string) {
+	for _, member := range gameserverservice.Instance().PartyMembers(client) {
+		cmdservice.Reply(member, text)
+	}
+}
@@ -2,38 +2,25 @@ package handlers
 
 import (
 	"log"
-	"reflect"
 	"time"
 
 	"github.com/lukehollenback/arcane-server/models"
 	"github.com/lukehollenback/arcane-server/models/msgmodels"
 	"github.com/lukehollenback/arcane-server/services/gameserverservice"
 	"github.com/lukehollenback/arcane-server/services/msghandlerservice"
-	"github.com/mitchellh/mapstructure"
 )
 
 const nanosInMilli = 1000000
 
 func init() {
-	msghandlerservice.Instance().RegisterMsgHandler(
-		reflect.TypeOf(new(msgmodels.Ping)).Elem().Name(),
-		false,
-		handlePing,
-	)
+	msghandlerservice.Instance().RegisterTypedMsgHandler(false, handlePing)
 }
 
 //
 // handlePing is intended to be registered with the Message Handler Service to be used to
 // actually processes a recieved message.
 //
-func handlePing(client *models.Client, rcvMsg *msgmodels.Msg) error {
-	//
-	// Deserialize the data payload in the message.
-	//
-	rcvMsgData := &msgmodels.Ping{}
-
-	mapstructure.Decode(rcvMsg.Data, rcvMsgData)
-
+func handlePing(client *models.Client, rcvMsg *msgmodels.Msg, rcvMsgData *msgmodels.Ping) error {
 	//
 	// Pull the timestamp out and turn it into a usable time struct.
 	//
@@ -61,7 +48,7 @@ func handlePing(client *models.Client, rcvMsg *msgmodels.Msg) error {
 		SentTime: (now.UnixNano() / nanosInMilli),
 	}
 
-	sndMsg := msgmodels.CreateMsg(sndMsgData)
+	sndMsg := msgmodels.CreateReply(rcvMsg, sndMsgData)
 
 	gameserverservice.Instance().SendMessage(client, sndMsg)
 
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/services/msghandlerservice"
+)
+
+func init() {
+	msghandlerservice.Instance().RegisterTypedMsgHandler(true, handleUDPBind)
+}
+
+//
+// handleUDPBind is intended to be registered with the Message Handler Service to be used to
+// actually processes a recieved message.
+//
+func handleUDPBind(
+	client *models.Client,
+	rcvMsg *msgmodels.Msg,
+	rcvMsgData *msgmodels.UDPBind,
+) error {
+	//
+	// Make sure that the client said it could use UDP during the protocol handshake.
+	//
+	if !client.HasCapability(msgmodels.CapUDP) {
+		return fmt.Errorf("the \"%s\" capability was not negotiated", msgmodels.CapUDP)
+	}
+
+	//
+	// Hand out a fresh UDP session token. The request's data payload carries nothing of interest.
+	//
+	token, port, err := gameserverservice.Instance().BindUDP(client)
+	if err != nil {
+		return err
+	}
+
+	//
+	// Tell the client what it needs to start sending datagrams.
+	//
+	sndMsgData := &msgmodels.UDPBind{
+		Token: token,
+		Port:  port,
+	}
+	sndMsg := msgmodels.CreateReply(rcvMsg, sndMsgData)
+
+	gameserverservice.Instance().SendMessage(client, sndMsg)
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/chatfilterservice"
+	"github.com/lukehollenback/arcane-server/services/cmdservice"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/services/msghandlerservice"
+	"github.com/lukehollenback/arcane-server/services/playerinfoservice"
+)
+
+func init() {
+	msghandlerservice.Instance().RegisterTypedMsgHandler(true, handleWhisper)
+
+	cmdservice.Instance().RegisterCommand(&cmdservice.Command{
+		Name:    "whisper",
+		Aliases: []string{"w", "tell", "msg"},
+		Usage:   "<player> <message>",
+		Summary: "Privately sends a message to another player.",
+		Args:    cmdservice.Args(2, 2),
+		Run:     runWhisper,
+	})
+
+	cmdservice.Instance().RegisterCommand(&cmdservice.Command{
+		Name:    "reply",
+		Aliases: []string{"r"},
+		Usage:   "<message>",
+		Summary: "Privately replies to whoever most recently whispered to you.",
+		Args:    cmdservice.Args(1, 1),
+		Run:     runReply,
+	})
+}
+
+//
+// handleWhisper is intended to be registered with the Message Handler Service to be used to
+// actually processes a recieved message. If the whisper can not be delivered, the returned error is
+// sent back to the client as a reply to the received message.
+//
+func handleWhisper(
+	client *models.Client,
+	rcvMsg *msgmodels.Msg,
+	rcvMsgData *msgmodels.Whisper,
+) error {
+	targetID, err := findWhisperTarget(rcvMsgData.Target)
+	if err != nil {
+		return err
+	}
+
+	return whisper(client, rcvMsg, targetID, rcvMsgData.Content)
+}
+
+//
+// runWhisper implements the "/whisper" chat command.
+//
+func runWhisper(client *models.Client, args []string) error {
+	targetID, err := findWhisperTarget(args[0])
+	if err != nil {
+		return err
+	}
+
+	return whisper(client, nil, targetID, args[1])
+}
+
+//
+// runReply implements the "/reply" chat command. The reply goes straight to the player that most
+// recently whispered to the client, even if their username is shared by somebody else.
+//
+func runReply(client *models.Client, args []string) error {
+	whisperer := client.Whisperer()
+	if len(whisperer) == 0 {
+		return errors.New("Nobody has whispered to you yet.")
+	}
+
+	return whisper(client, nil, whisperer, args[0])
+}
+
+//
+// findWhisperTarget resolves the specified username to the ID of the player that whispers to it
+// should be delivered to. If that can not be done, an error explaining why (that is suitable for
+// showing to the sender) is returned.
+//
+func findWhisperTarget(targetUsername string) (string, error) {
+	targetID, err := playerinfoservice.Instance().FindPlayerID(targetUsername)
+
+	switch err {
+	case nil:
+		return targetID, nil
+	case playerinfoservice.ErrUsernameAmbiguous:
+		return "", fmt.Errorf("More than one player is named \"%s\", so your whisper was not sent.", targetUsername)
+	default:
+		return "", fmt.Errorf("There is no player named \"%s\".", targetUsername)
+	}
+}
+
+//
+// whisper privately delivers the provided content from the provided client to the player with the
+// specified ID, and echoes it back to the sender. If the whisper was requested by a message, the
+// echo is sent as a reply to it. If the whisper can not be delivered, an error explaining why (that
+// is suitable for showing to the sender) is returned.
+//
+func whisper(
+	client *models.Client,
+	rcvMsg *msgmodels.Msg,
+	targetID string,
+	content string,
+) error {
+	content, err := chatfilterservice.Instance().Sanitize(content)
+	if err != nil {
+		return fmt.Errorf("Your whisper was not sent. (Reason: %s)", err)
+	}
+
+	//
+	// Find the recipient. Only players that are currently online can be whispered to.
+	//
+	if targetID == client.PlayerID() {
+		return errors.New("You can not whisper to yourself.")
+	}
+
+	target, prs := gameserverservice.Instance().FindClientByPlayerID(targetID)
+	if !prs {
+		return fmt.Errorf("%s is not online.", playerinfoservice.Instance().GetUsername(targetID))
+	}
+
+	//
+	// Deliver the whisper to the recipient, remembering who it came from so that they can quickly
+	// reply, and then echo it back to the sender so that they can see that it went through.
+	//
+	sndMsgData := &msgmodels.Whisper{
+		Author:  playerinfoservice.Instance().GetUsername(client.PlayerID()),
+		Target:  playerinfoservice.Instance().GetUsername(targetID),
+		Content: content,
+	}
+
+	target.SetWhisperer(client.PlayerID())
+
+	gameserverservice.Instance().SendMessage(target, msgmodels.CreateMsg(sndMsgData))
+
+	echoMsg := msgmodels.CreateMsg(sndMsgData)
+	if rcvMsg != nil {
+		echoMsg = msgmodels.CreateReply(rcvMsg, sndMsgData)
+	}
+
+	gameserverservice.Instance().SendMessage(client, echoMsg)
+
+	log.Printf("%sWhispered to player %s.", client.LogPrefix(), targetID)
+
+	return nil
+}
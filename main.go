@@ -5,10 +5,16 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 
 	"github.com/lukehollenback/arcane-server/handlers"
+	"github.com/lukehollenback/arcane-server/services/anticheatservice"
+	"github.com/lukehollenback/arcane-server/services/authservice"
+	"github.com/lukehollenback/arcane-server/services/chatfilterservice"
 	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/services/msghandlerservice"
 	"github.com/lukehollenback/arcane-server/services/playerinfoservice"
+	"github.com/lukehollenback/arcane-server/services/worldservice"
 	"github.com/lukehollenback/arcane-server/util"
 )
 
@@ -47,11 +53,225 @@ func main() {
 			"\"TCP_BIND_PORT\" environment variable.",
 		)
 
+	udpBindPort := flag.String(
+		"udpport", util.GetEnv("UDP_BIND_PORT", ""),
+		"The UDP port that the server should bind to for receiving unreliable position updates. If "+
+			"unset, UDP is not used. Can also be specified via the \"UDP_BIND_PORT\" environment "+
+			"variable.",
+	)
+
+	wsBindAddress := flag.String(
+		"wsaddr", util.GetEnv("WS_BIND_ADDRESS", ""),
+		"The address (e.g. \"localhost:6544\") that the server should bind to for listening for "+
+			"WebSocket connections. If unset, WebSocket connections are not accepted. Can also be "+
+			"specified via the \"WS_BIND_ADDRESS\" environment variable.",
+	)
+
+	wsOrigins := flag.String(
+		"wsorigins", util.GetEnv("WS_ALLOWED_ORIGINS", ""),
+		"A comma-separated list of the origins (e.g. \"https://play.example.com\") of web pages "+
+			"other than the server's own that may open WebSocket connections. \"*\" allows pages "+
+			"from anywhere. Can also be specified via the \"WS_ALLOWED_ORIGINS\" environment "+
+			"variable.",
+	)
+
+	wsPath := flag.String(
+		"wspath", util.GetEnv("WS_PATH", "/ws"),
+		"The HTTP path at which WebSocket connections are accepted. Can also be specified via the "+
+			"\"WS_PATH\" environment variable.",
+	)
+
+	authTokenFile := flag.String(
+		"authtokens", util.GetEnv("AUTH_TOKEN_FILE", ""),
+		"The path to a file of static \"{token} {playerID} [{username} [{roles}]]\" entries that "+
+			"should be used to authenticate players, where \"{roles}\" is a comma-separated list (e.g. "+
+			"\"moderator,admin\"). Only intended for local development. Takes precedence over "+
+			"JWT verification if set. Can also be specified via the \"AUTH_TOKEN_FILE\" environment "+
+			"variable.",
+	)
+
+	authJWTSecret := flag.String(
+		"jwtsecret", util.GetEnv("AUTH_JWT_SECRET", ""),
+		"The shared secret that player JWT authentication tokens are signed with. Can also be "+
+			"specified via the \"AUTH_JWT_SECRET\" environment variable.",
+	)
+
+	authJWTAudience := flag.String(
+		"jwtaudience", util.GetEnv("AUTH_JWT_AUDIENCE", "arcane-server"),
+		"The audience that player JWT authentication tokens must have been issued for. Can also be "+
+			"specified via the \"AUTH_JWT_AUDIENCE\" environment variable.",
+	)
+
+	authMaxFailures := flag.Int(
+		"authmaxfailures", 3,
+		"The number of failed authentication attempts after which a client is disconnected.",
+	)
+
+	playerDBPath := flag.String(
+		"playerdb", util.GetEnv("PLAYER_DB_PATH", "players.db"),
+		"The path to the embedded database file that player records are persisted in. Can also be "+
+			"specified via the \"PLAYER_DB_PATH\" environment variable.",
+	)
+
+	playerFlushSecs := flag.Int(
+		"playerflush", 30,
+		"How often (in seconds) modified player records are saved to the embedded database. Zero only "+
+			"saves them when they are evicted from the cache and during shut-down.",
+	)
+
+	areasFile := flag.String(
+		"areas", util.GetEnv("AREAS_FILE", ""),
+		"The path to a JSON file defining the areas that exist in the game world. If unset, only the "+
+			"default spawn area exists. Can also be specified via the \"AREAS_FILE\" environment variable.",
+	)
+
+	worldTickHz := flag.Int(
+		"tickhz", 20,
+		"The number of times per second that the world simulation should be advanced.",
+	)
+
+	syncFlushMs := flag.Int(
+		"syncflushms", 50,
+		"The number of milliseconds between flushes of batched object synchronizations to clients.",
+	)
+
+	antiCheatMaxSpeed := flag.Float64(
+		"maxspeed", 400,
+		"The maximum distance that a player character may move per second before it is considered to "+
+			"be cheating. Zero disables the check.",
+	)
+
+	antiCheatViolations := flag.Int(
+		"maxviolations", 10,
+		"The number of anti-cheat violations within the violation window after which a client is "+
+			"kicked. Zero disables kicking.",
+	)
+
+	antiCheatViolationWindow := flag.Int(
+		"violationwindow", 60,
+		"The number of seconds that each anti-cheat violation counts against a client for. Zero makes "+
+			"violations count forever.",
+	)
+
+	maxPanics := flag.Int(
+		"maxpanics", 3,
+		"The number of server errors (i.e. recovered panics) that a client's messages may cause before "+
+			"it is disconnected. Zero disables disconnecting.",
+	)
+
+	chatMaxLength := flag.Int(
+		"chatmaxlen", 256,
+		"The maximum number of characters that a chat message may have. Zero disables the limit.",
+	)
+
+	chatWordsFile := flag.String(
+		"chatwords", util.GetEnv("CHAT_WORDS_FILE", ""),
+		"The path to a file listing words or phrases (one per line) that are not allowed in chat messages. If "+
+			"unset, no words are filtered. Can also be specified via the \"CHAT_WORDS_FILE\" environment "+
+			"variable.",
+	)
+
+	chatFilterMode := flag.String(
+		"chatfilter", chatfilterservice.ModeMask,
+		"What happens to chat messages containing filtered words. Either \"mask\" (the words are "+
+			"masked out) or \"reject\" (the messages are not sent).",
+	)
+
 	flag.Parse()
 
+	//
+	// Configure the Auth Service with the appropriate token verifier backend.
+	//
+	var verifier authservice.TokenVerifier
+
+	switch {
+	case len(*authTokenFile) > 0:
+		verifier, err = authservice.CreateStaticTokenVerifier(*authTokenFile)
+		if err != nil {
+			log.Fatalf("Failed to load the static authentication token file. (Error: %s)", err)
+		}
+
+		log.Printf("Using static authentication tokens from \"%s\".", *authTokenFile)
+	case len(*authJWTSecret) > 0:
+		verifier = authservice.CreateJWTVerifier([]byte(*authJWTSecret), *authJWTAudience)
+	default:
+		log.Fatal("No authentication token verifier has been configured. (Hint: Specify either a JWT " +
+			"secret or a static token file.)")
+	}
+
+	authservice.Instance().Config(&authservice.Config{
+		Verifier:    verifier,
+		MaxFailures: *authMaxFailures,
+	})
+
+	//
+	// Configure the Anti-Cheat Service.
+	//
+	anticheatservice.Instance().Config(&anticheatservice.Config{
+		MaxSpeed:            *antiCheatMaxSpeed,
+		ViolationThreshold:  *antiCheatViolations,
+		ViolationWindowSecs: *antiCheatViolationWindow,
+	})
+
+	//
+	// Configure the Chat Filter Service.
+	//
+	if *chatFilterMode != chatfilterservice.ModeMask && *chatFilterMode != chatfilterservice.ModeReject {
+		log.Fatalf("Unknown chat filter mode \"%s\".", *chatFilterMode)
+	}
+
+	var chatWords []string
+
+	if len(*chatWordsFile) > 0 {
+		chatWords, err = chatfilterservice.LoadWordList(*chatWordsFile)
+		if err != nil {
+			log.Fatalf("Failed to load the chat word list file. (Error: %s)", err)
+		}
+
+		log.Printf("Filtering %d words from chat using \"%s\".", len(chatWords), *chatWordsFile)
+	}
+
+	chatfilterservice.Instance().Config(&chatfilterservice.Config{
+		MaxLength: *chatMaxLength,
+		Words:     chatWords,
+		Mode:      *chatFilterMode,
+	})
+
+	//
+	// Configure the Message Handler Service so that it can tell clients about requests that could not
+	// be handled, and get rid of clients whose requests keep causing server errors.
+	//
+	msghandlerservice.Instance().Config(&msghandlerservice.Config{
+		Send:       gameserverservice.Instance().SendMessage,
+		Disconnect: gameserverservice.Instance().Disconnect,
+		MaxPanics:  *maxPanics,
+	})
+
+	//
+	// Set up the middleware chain that every message passes through on its way to its handler.
+	//
+	metrics := msghandlerservice.CreateMetrics()
+	rateLimiter := msghandlerservice.CreateRateLimiter(
+		msghandlerservice.RateLimit{PerSec: 20, Burst: 40},
+		map[string]msghandlerservice.RateLimit{
+			"ObjSync": {PerSec: 60, Burst: 120},
+			"Chat":    {PerSec: 2, Burst: 5},
+			"Whisper": {PerSec: 2, Burst: 5},
+		},
+	)
+
+	msghandlerservice.Instance().Use(msghandlerservice.Logging)
+	msghandlerservice.Instance().Use(metrics.Middleware)
+	msghandlerservice.Instance().Use(rateLimiter.Middleware)
+
 	//
 	// Start the Player Info Service.
 	//
+	playerinfoservice.Instance().Config(&playerinfoservice.Config{
+		Store:             playerinfoservice.CreateBoltStore(*playerDBPath),
+		CacheSize:         1024,
+		FlushIntervalSecs: *playerFlushSecs,
+	})
 	ch, err = playerinfoservice.Instance().Start()
 	if err != nil {
 		log.Fatalf("Failed to start the Player Info Service. (Error: %s)", err)
@@ -62,9 +282,38 @@ func main() {
 	//
 	// Start the Game Server Service.
 	//
+	areas := gameserverservice.DefaultAreas()
+
+	if len(*areasFile) > 0 {
+		areas, err = gameserverservice.LoadAreas(*areasFile)
+		if err != nil {
+			log.Fatalf("Failed to load the area definitions file. (Error: %s)", err)
+		}
+	}
+
+	wsAllowedOrigins := make([]string, 0)
+
+	for _, origin := range strings.Split(*wsOrigins, ",") {
+		if origin = strings.TrimSpace(origin); len(origin) > 0 {
+			wsAllowedOrigins = append(wsAllowedOrigins, origin)
+		}
+	}
+
+	udpBindAddress := ""
+
+	if len(*udpBindPort) > 0 {
+		udpBindAddress = *tcpBindAddress + ":" + *udpBindPort
+	}
+
 	gameserverservice.Instance().Config(&gameserverservice.Config{
 		TCPAddr:                    *tcpBindAddress + ":" + *tcpBindPort,
+		WSAddr:                     *wsBindAddress,
+		WSPath:                     *wsPath,
+		WSAllowedOrigins:           wsAllowedOrigins,
+		UDPAddr:                    udpBindAddress,
 		ClientHeartbeatTimeoutSecs: 60,
+		SyncFlushIntervalMs:        *syncFlushMs,
+		Areas:                      areas,
 	})
 	ch, err = gameserverservice.Instance().Start()
 	if err != nil {
@@ -73,6 +322,19 @@ func main() {
 
 	<-ch
 
+	//
+	// Start the World Service.
+	//
+	worldservice.Instance().Config(&worldservice.Config{
+		TickHz: *worldTickHz,
+	})
+	ch, err = worldservice.Instance().Start()
+	if err != nil {
+		log.Fatalf("Failed to start the World Service. (Error: %s)", err)
+	}
+
+	<-ch
+
 	//
 	// Log some debug info.
 	//
@@ -85,6 +347,16 @@ func main() {
 
 	log.Print("An operating system interrupt has been received. Shutting down all services...")
 
+	//
+	// Shut down the World Service.
+	//
+	ch, err = worldservice.Instance().Stop()
+	if err != nil {
+		log.Fatalf("Failed to stop the World Service. (Error: %s)", err)
+	}
+
+	<-ch
+
 	//
 	// Shut down the Game Server Service.
 	//
@@ -105,6 +377,11 @@ func main() {
 
 	<-ch
 
+	//
+	// Log a summary of how much time was spent handling each type of message.
+	//
+	metrics.LogSummary()
+
 	//
 	// Wrap everything up.
 	//
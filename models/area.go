@@ -0,0 +1,48 @@
+package models
+
+//
+// Area represents a distinct region (e.g. a room) of the game world that objects can reside in.
+//
+// NOTE: We intentionally make all members of this class public to help with both serialization and
+//  with logging.
+//
+type Area struct {
+  ID         string // The unique identifier of the area. Should reference a literal room name/identifier on the client.
+  Width      int    // The horizontal size of the area. Zero if the area is unbounded.
+  Height     int    // The vertical size of the area. Zero if the area is unbounded.
+  SpawnX     int    // The horizontal location that objects entering the area without an explicit location are placed at.
+  SpawnY     int    // The vertical location that objects entering the area without an explicit location are placed at.
+  SpawnDepth int    // The depth that objects entering the area are placed at.
+}
+
+//
+// Clamp constrains the provided location to the bounds of the area. Unbounded dimensions are left
+// alone.
+//
+func (o *Area) Clamp(x int, y int) (int, int) {
+  if o.Width > 0 {
+    x = clampInt(x, 0, o.Width)
+  }
+
+  if o.Height > 0 {
+    y = clampInt(y, 0, o.Height)
+  }
+
+  return x, y
+}
+
+//
+// clampInt constrains the provided value to the inclusive range between the provided minimum and
+// maximum.
+//
+func clampInt(val int, min int, max int) int {
+  if val < min {
+    return min
+  }
+
+  if val > max {
+    return max
+  }
+
+  return val
+}
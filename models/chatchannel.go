@@ -0,0 +1,38 @@
+package models
+
+const (
+  //
+  // ChatScopeArea is the scope of chat channels whose messages reach everybody in the sender's area.
+  //
+  ChatScopeArea = "area"
+
+  //
+  // ChatScopeGlobal is the scope of chat channels whose messages reach everybody on the server.
+  //
+  ChatScopeGlobal = "global"
+
+  //
+  // ChatScopeMembers is the scope of opt-in chat channels whose messages only reach those that have
+  // joined them.
+  //
+  ChatScopeMembers = "members"
+
+  //
+  // ChatScopeParty is the scope of chat channels whose messages only reach the members of the
+  // sender's party.
+  //
+  ChatScopeParty = "party"
+)
+
+//
+// ChatChannel represents a channel that chat messages can be sent to.
+//
+// NOTE: We intentionally make all members of this class public to help with both serialization and
+//  with logging.
+//
+type ChatChannel struct {
+  Name     string // The unique identifier of the channel that chat messages carry to target it (e.g. "trade").
+  Scope    string // Who receives messages sent to the channel. See the "ChatScope*" constants.
+  JoinRole string // The role required to join the channel. Empty if anybody may. Only applies to opt-in channels.
+  SendRole string // The role required to send messages to the channel. Empty if anybody that receives them may.
+}
@@ -6,33 +6,112 @@ import (
   "sync"
   "time"
 
-  "github.com/lukehollenback/packet-server/tcp"
+  "github.com/lukehollenback/arcane-server/models/msgmodels"
+  "github.com/lukehollenback/arcane-server/util"
+)
+
+const (
+  //
+  // DefaultAreaID is the identifier of the area that newly connected clients spawn into.
+  //
+  DefaultAreaID = "SecretMountain"
+
+  //
+  // DefaultX is the horizontal location that newly connected clients spawn at.
+  //
+  DefaultX = 224
+
+  //
+  // DefaultY is the vertical location that newly connected clients spawn at.
+  //
+  DefaultY = 160
+
+  //
+  // DefaultDepth is the depth that newly connected clients spawn at.
+  //
+  DefaultDepth = 400
+)
+
+const (
+  //
+  // ObjTypePlayer is the object name that a client knows its own player character by.
+  //
+  ObjTypePlayer = "oPlayer"
+
+  //
+  // ObjTypeOtherPlayer is the object name that a client knows other clients' player characters by.
+  //
+  ObjTypeOtherPlayer = "oOtherPlayer"
+)
+
+const (
+  //
+  // SyncVarX is the synchronized variable key holding an object's horizontal location.
+  //
+  SyncVarX = "x"
+
+  //
+  // SyncVarY is the synchronized variable key holding an object's vertical location.
+  //
+  SyncVarY = "y"
+
+  //
+  // SyncVarDepth is the synchronized variable key holding an object's depth.
+  //
+  SyncVarDepth = "depth"
 )
 
 //
 // Client represents a connected player.
 //
 type Client struct {
-  mu        *sync.Mutex // Mutex to prevent concurrent modification issues when mutating struct members.
-  tcpClient *tcp.Client // The actual TCP/IP packet server client instance that is interacting with the client.
-  authed    bool        // Whether or not the client has successfully authenticated yet. Some message handlers will fail until this is true.
-  authedID  string      // The Player ID that the client authenticated themselves to be.
-  objectID  uuid.UUID   // The unique identifier for the object instance representing the client.
-  lastMsg   time.Time   // Timestamp of when the last known message was received from the client.
+  mu         *sync.Mutex     // Mutex to prevent concurrent modification issues when mutating struct members.
+  handlerMu  *sync.Mutex     // Mutex to ensure that only one of the client's messages is handled at a time. See LockHandling().
+  closeOnce  *sync.Once      // Ensures that the underlying connection is only ever asked to close once.
+  closed     bool            // Whether or not the connection to the client has been asked to close.
+  conn       Connection      // The actual connection (e.g. TCP/IP or WebSocket) that is interacting with the client.
+  authed     bool            // Whether or not the client has successfully authenticated yet. Some message handlers will fail until this is true.
+  authedID   string          // The Player ID that the client authenticated themselves to be.
+  authFails  int             // Number of failed authentication attempts made by the client.
+  protocol   int             // The protocol version negotiated with the client. Zero until the handshake has been performed.
+  caps       map[string]bool // The optional capabilities negotiated with the client.
+  objectID   uuid.UUID       // The unique identifier for the object instance representing the client.
+  lastMsg    time.Time       // Timestamp of when the last known message was received from the client.
+  codec      msgmodels.Codec // The wire format that messages to and from the client are serialized with.
+  areaID     string          // The unique identifier of the area that the client's object currently resides in.
+  x          int             // The current horizontal location of the client's object.
+  y          int             // The current vertical location of the client's object.
+  depth      int             // The current depth of the client's object.
+  validX     int             // The horizontal location most recently accepted by anti-cheat validation.
+  validY     int             // The vertical location most recently accepted by anti-cheat validation.
+  validAt    time.Time       // When the location most recently accepted by anti-cheat validation was received.
+  violations []time.Time     // When each of the anti-cheat violations that still count against the client was racked up.
+  panics     int             // Number of panics that the client's messages have caused while being handled.
+  whisperer  string          // The player ID of whoever most recently whispered to the client.
 }
 
 //
 // CreateClient constructs a new client structure instance (to represent a connected player) and
 // returns a pointer to it.
 //
-func CreateClient(tcpClient *tcp.Client) *Client {
+func CreateClient(conn Connection) *Client {
   client := &Client{
     mu:        &sync.Mutex{},
-    tcpClient: tcpClient,
+    handlerMu: &sync.Mutex{},
+    closeOnce: &sync.Once{},
+    conn:      conn,
     authed:    false,
     authedID:  "Unknown",
     objectID:  uuid.New(),
     lastMsg:   time.Now(),
+    codec:     msgmodels.JSONCodec{},
+    areaID:    DefaultAreaID,
+    x:         DefaultX,
+    y:         DefaultY,
+    depth:     DefaultDepth,
+    validX:    DefaultX,
+    validY:    DefaultY,
+    validAt:   time.Now(),
   }
 
   return client
@@ -42,22 +121,79 @@ func CreateClient(tcpClient *tcp.Client) *Client {
 // String returns a string explanation of the client.
 //
 func (o *Client) String() string {
-  return fmt.Sprintf("username: %s, authed: %t, lastMsg: %s, tcpRemoteAddr: %s, tcpLocalAddr: %s",
-    o.authedID, o.authed, o.lastMsg, o.TCPRemoteAddr(), o.TCPLocalAddr())
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return fmt.Sprintf("username: %s, authed: %t, lastMsg: %s, remoteAddr: %s",
+    o.authedID, o.authed, o.lastMsg, o.RemoteAddr())
+}
+
+//
+// ID returns the unique identifier of the connection to the client.
+//
+func (o *Client) ID() int {
+  return o.conn.ID()
+}
+
+//
+// Send sends the provided serialized message to the client over its connection.
+//
+func (o *Client) Send(b []byte) error {
+  return o.conn.Send(b)
+}
+
+//
+// Close begins the process of closing the connection to the client. It is safe to call this more
+// than once (e.g. if a client manages to get kicked for two different reasons at the same time).
+//
+func (o *Client) Close() {
+  o.closeOnce.Do(func() {
+    o.mu.Lock()
+    o.closed = true
+    o.mu.Unlock()
+
+    o.conn.Close()
+  })
+}
+
+//
+// Closed returns whether or not the connection to the client has been asked to close (e.g. because
+// it was kicked), in which case nothing more that it sends should be acted upon.
+//
+func (o *Client) Closed() bool {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.closed
+}
+
+//
+// Codec returns the wire format that messages to and from the client are serialized with.
+//
+func (o *Client) Codec() msgmodels.Codec {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.codec
 }
 
 //
-// TCPClient returns a pointer to the actual TCP/IP client object that can be used to communicate
-// with the client.
+// SetCodec modifies the wire format that messages to and from the client are serialized with.
 //
-func (o *Client) TCPClient() *tcp.Client {
-  return o.tcpClient
+func (o *Client) SetCodec(codec msgmodels.Codec) {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  o.codec = codec
 }
 
 //
 // Authed returns whether or not the client has successfully authenticated yet.
 //
 func (o *Client) Authed() bool {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
   return o.authed
 }
 
@@ -76,6 +212,9 @@ func (o *Client) SetAuthed(authed bool) {
 // identifier of the player's record in the database.
 //
 func (o *Client) PlayerID() string {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
   return o.authedID
 }
 
@@ -91,24 +230,78 @@ func (o *Client) SetPlayerID(authedID string) {
 }
 
 //
-// TCPRemoteAddr returns the remote address string for the TCP connection to the client.
+// Negotiated returns whether or not the protocol handshake has been performed with the client yet.
+//
+func (o *Client) Negotiated() bool {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.protocol > 0
+}
+
+//
+// ProtocolVersion returns the protocol version negotiated with the client, or zero if the protocol
+// handshake has not been performed yet.
 //
-func (o *Client) TCPRemoteAddr() string {
-  return o.tcpClient.RemoteAddr()
+func (o *Client) ProtocolVersion() int {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.protocol
 }
 
 //
-// TCPLocalAddr returns the local address string for the TCP connection to the client.
+// HasCapability returns whether or not the specified optional capability (e.g. "udp") was
+// negotiated with the client during the protocol handshake.
 //
-func (o *Client) TCPLocalAddr() string {
-  return o.tcpClient.LocalAddr()
+func (o *Client) HasCapability(capability string) bool {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.caps[capability]
+}
+
+//
+// SetProtocol records the protocol version and optional capabilities negotiated with the client
+// during the protocol handshake.
+//
+func (o *Client) SetProtocol(version int, capabilities []string) {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  o.protocol = version
+  o.caps = make(map[string]bool, len(capabilities))
+
+  for _, capability := range capabilities {
+    o.caps[capability] = true
+  }
+}
+
+//
+// IncAuthFailures records a failed authentication attempt by the client and returns the total
+// number of failed attempts made so far.
+//
+func (o *Client) IncAuthFailures() int {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  o.authFails++
+
+  return o.authFails
+}
+
+//
+// RemoteAddr returns the remote address string for the connection to the client.
+//
+func (o *Client) RemoteAddr() string {
+  return o.conn.RemoteAddr()
 }
 
 //
 // LogPrefix generates a prefix string that can be used in log messages about the client.
 //
 func (o *Client) LogPrefix() string {
-  return o.tcpClient.LogPrefix()
+  return o.conn.LogPrefix()
 }
 
 //
@@ -116,7 +309,7 @@ func (o *Client) LogPrefix() string {
 // the client.
 //
 func (o *Client) SndLogPrefix() string {
-  return o.tcpClient.SndLogPrefix()
+  return o.conn.SndLogPrefix()
 }
 
 //
@@ -124,7 +317,7 @@ func (o *Client) SndLogPrefix() string {
 // from the client.
 //
 func (o *Client) RcvLogPrefix() string {
-  return o.tcpClient.RcvLogPrefix()
+  return o.conn.RcvLogPrefix()
 }
 
 //
@@ -132,9 +325,30 @@ func (o *Client) RcvLogPrefix() string {
 // Can be used to check if the client is still connected and responding as expected.
 //
 func (o *Client) LastMsgTimestamp() time.Time {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
   return o.lastMsg
 }
 
+//
+// LockHandling blocks until none of the client's other messages are being handled, and then claims
+// the right to handle one. This keeps messages that arrive over different transports (e.g. TCP/IP
+// and UDP) from being handled concurrently, so that handlers can safely read, validate, and then
+// update the client's state. UnlockHandling() must be called once the message has been handled.
+//
+func (o *Client) LockHandling() {
+  o.handlerMu.Lock()
+}
+
+//
+// UnlockHandling gives up the right to handle one of the client's messages that was claimed by
+// LockHandling().
+//
+func (o *Client) UnlockHandling() {
+  o.handlerMu.Unlock()
+}
+
 //
 // UpdateLastMsgTimestamp sets the timestamp of the client's last known received message to the
 // current time.
@@ -153,30 +367,194 @@ func (o *Client) ObjectID() string {
   return o.objectID.String()
 }
 
+//
+// Implementation of Object.Type().
+//
+func (o *Client) Type() string {
+  return ObjTypePlayer
+}
+
 //
 // Implementation of Object.AreaID().
 //
 func (o *Client) AreaID() string {
-  return "SecretMountain"
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.areaID
 }
 
 //
 // Implementation of Object.X().
 //
 func (o *Client) X() int {
-  return 224
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.x
 }
 
 //
 // Implementation of Object.Y().
 //
 func (o *Client) Y() int {
-  return 160
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.y
 }
 
 //
 // Implementation of Object.Depth().
 //
 func (o *Client) Depth() int {
-  return 400
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.depth
+}
+
+//
+// ApplySyncVars updates the client object's position and depth from any of the well-known
+// synchronized variables (e.g. "x") present in the provided variable payload of an "ObjSync"-type
+// message. Variables that are missing or of the wrong type are ignored.
+//
+// NOTE: A client's area can not be changed this way. See SetLocation() instead.
+//
+func (o *Client) ApplySyncVars(vars map[string]interface{}) {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  if x, ok := util.GetIntVal(vars[SyncVarX]); ok {
+    o.x = x
+  }
+
+  if y, ok := util.GetIntVal(vars[SyncVarY]); ok {
+    o.y = y
+  }
+
+  if depth, ok := util.GetIntVal(vars[SyncVarDepth]); ok {
+    o.depth = depth
+  }
+}
+
+//
+// SetLocation moves the client's object to the specified position and depth within the specified
+// area.
+//
+func (o *Client) SetLocation(areaID string, x int, y int, depth int) {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  o.areaID = areaID
+  o.x = x
+  o.y = y
+  o.depth = depth
+
+  //
+  // Being placed somewhere by the server is not something that anti-cheat validation should
+  // consider to be movement.
+  //
+  o.validX = x
+  o.validY = y
+  o.validAt = time.Now()
+}
+
+//
+// LastValidPosition returns the location of the client's object that was most recently accepted by
+// anti-cheat validation, along with when it was received.
+//
+func (o *Client) LastValidPosition() (int, int, time.Time) {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.validX, o.validY, o.validAt
+}
+
+//
+// SetLastValidPosition records a location of the client's object that has been accepted by
+// anti-cheat validation, along with when it was received.
+//
+func (o *Client) SetLastValidPosition(x int, y int, at time.Time) {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  o.validX = x
+  o.validY = y
+  o.validAt = at
+}
+
+//
+// IncViolations records an anti-cheat violation by the client and returns the number of violations
+// racked up within the provided window of time (including this one). Violations older than the
+// window are forgotten, so that occasional false positives (e.g. from network jitter) never add up
+// to a kick. A window of zero means that violations are never forgotten.
+//
+func (o *Client) IncViolations(window time.Duration) int {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  now := time.Now()
+
+  if window > 0 {
+    cutoff := now.Add(-window)
+    recent := o.violations[:0]
+
+    for _, at := range o.violations {
+      if at.After(cutoff) {
+        recent = append(recent, at)
+      }
+    }
+
+    o.violations = recent
+  }
+
+  o.violations = append(o.violations, now)
+
+  return len(o.violations)
+}
+
+//
+// IncPanics records a panic caused by one of the client's messages and returns the total number of
+// panics caused so far.
+//
+func (o *Client) IncPanics() int {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  o.panics++
+
+  return o.panics
+}
+
+//
+// Panics returns the total number of panics that the client's messages have caused.
+//
+func (o *Client) Panics() int {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.panics
+}
+
+//
+// Whisperer returns the player ID of whoever most recently whispered to the client, or an empty
+// string if nobody has yet.
+//
+func (o *Client) Whisperer() string {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  return o.whisperer
+}
+
+//
+// SetWhisperer records the player ID of whoever most recently whispered to the client so that the
+// client can quickly reply to them.
+//
+func (o *Client) SetWhisperer(playerID string) {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  o.whisperer = playerID
 }
@@ -0,0 +1,50 @@
+package models
+
+//
+// Connection represents the underlying connection that a client is communicating over. Clients
+// depend only on this interface so that any transport (e.g. TCP/IP, WebSockets, or an in-memory
+// pipe) can be plugged in by way of an adapter. See the "transport" package for the available
+// adapters.
+//
+type Connection interface {
+  //
+  // ID returns the unique identifier that has been assigned to the connection. It must not collide
+  // with the identifier of any other open connection, regardless of transport.
+  //
+  ID() int
+
+  //
+  // LogPrefix generates a prefix string that can be used in log messages about the connection.
+  //
+  LogPrefix() string
+
+  //
+  // RcvLogPrefix generates a prefix string that can be used in log messages about messages
+  // recieved over the connection.
+  //
+  RcvLogPrefix() string
+
+  //
+  // SndLogPrefix generates a prefix string that can be used in log messages about messages sent
+  // over the connection.
+  //
+  SndLogPrefix() string
+
+  //
+  // RemoteAddr returns an address string (e.g. "{ip}:{port}") for the remote end of the
+  // connection.
+  //
+  RemoteAddr() string
+
+  //
+  // Close begins the process of closing the connection. It returns a channel that can optionally
+  // be blocked on if the caller would like to know when the connection has been completely closed.
+  //
+  Close() <-chan bool
+
+  //
+  // Send sends the specified serialized message over the connection, framing it however the
+  // transport requires.
+  //
+  Send(b []byte) error
+}
@@ -0,0 +1,12 @@
+package msgmodels
+
+//
+// AreaChange represents the structure of a message that a client sends when its player character
+// wants to move into a different area, and that the server sends back once the move has happened.
+//
+type AreaChange struct {
+  AreaID string // The unique ID of the area that the player character is moving into.
+  X      int    // The horizontal location that the player character ended up at. Only set by the server.
+  Y      int    // The vertical location that the player character ended up at. Only set by the server.
+  Depth  int    // The depth that the player character ended up at. Only set by the server.
+}
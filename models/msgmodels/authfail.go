@@ -0,0 +1,10 @@
+package msgmodels
+
+//
+// AuthFail represents the data payload of a "AuthFail"-type message, which tells a client that the
+// token it provided in an "Auth"-type message could not be verified.
+//
+type AuthFail struct {
+	Reason            string // A message explaining why the token was rejected.
+	AttemptsRemaining int    // How many more failed attempts the client may make before being disconnected.
+}
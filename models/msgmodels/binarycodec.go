@@ -0,0 +1,467 @@
+package msgmodels
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+//
+// Tags that prefix each value in the binary encoding to identify its type.
+//
+const (
+	binNil byte = iota
+	binFalse
+	binTrue
+	binInt
+	binFloat
+	binString
+	binArray
+	binMap
+)
+
+//
+// binMaxDepth is the deepest that values may be nested before decoding gives up. It keeps malicious
+// payloads from blowing the stack.
+//
+const binMaxDepth = 32
+
+//
+// BinaryCodec is a codec that serializes messages into a compact, length-prefixed binary format.
+//
+// Each message is encoded as a varint length followed by the message itself, which is encoded as a
+// tree of tagged values (nil, booleans, zig-zag varint integers, floats, length-prefixed strings,
+// arrays, and string-keyed maps). Structs are encoded as maps of their exported fields. The result
+// is then run through consistent overhead byte stuffing so that it contains no NUL bytes and can be
+// carried by the same NUL-delimited transport as JSON.
+//
+type BinaryCodec struct{}
+
+//
+// Name implements the method defined by the Codec interface.
+//
+func (o BinaryCodec) Name() string {
+	return CodecBinary
+}
+
+//
+// Encode implements the method defined by the Codec interface.
+//
+func (o BinaryCodec) Encode(msg *Msg) ([]byte, error) {
+	body, err := appendBinValue(nil, reflect.ValueOf(msg))
+	if err != nil {
+		return nil, err
+	}
+
+	frame := appendUvarint(make([]byte, 0, len(body)+binary.MaxVarintLen64), uint64(len(body)))
+	frame = append(frame, body...)
+
+	return cobsEncode(frame), nil
+}
+
+//
+// Decode implements the method defined by the Codec interface.
+//
+func (o BinaryCodec) Decode(raw []byte) (*Msg, error) {
+	frame, err := cobsDecode(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	length, n := binary.Uvarint(frame)
+	if n <= 0 || uint64(len(frame)-n) != length {
+		return nil, errors.New("the message's length prefix does not match its actual length")
+	}
+
+	val, rest, err := readBinValue(frame[n:], 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, errors.New("the message has trailing bytes")
+	}
+
+	fields, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the message is not a map")
+	}
+
+	key, _ := fields["Key"].(string)
+	if len(key) == 0 {
+		return nil, errors.New("the message has no key")
+	}
+
+	id, _ := fields["ID"].(string)
+
+	return &Msg{
+		ID:   id,
+		Key:  key,
+		Data: fields["Data"],
+	}, nil
+}
+
+//
+// appendBinValue encodes the provided value and appends it to the provided buffer.
+//
+func appendBinValue(buf []byte, val reflect.Value) ([]byte, error) {
+	if !val.IsValid() {
+		return append(buf, binNil), nil
+	}
+
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if val.IsNil() {
+			return append(buf, binNil), nil
+		}
+
+		return appendBinValue(buf, val.Elem())
+
+	case reflect.Bool:
+		if val.Bool() {
+			return append(buf, binTrue), nil
+		}
+
+		return append(buf, binFalse), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendVarint(append(buf, binInt), val.Int()), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if val.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("unsigned value %d is too large to encode", val.Uint())
+		}
+
+		return appendVarint(append(buf, binInt), int64(val.Uint())), nil
+
+	case reflect.Float32, reflect.Float64:
+		buf = append(buf, binFloat)
+
+		return appendUint64(buf, math.Float64bits(val.Float())), nil
+
+	case reflect.String:
+		return appendBinString(append(buf, binString), val.String()), nil
+
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return append(buf, binNil), nil
+		}
+
+		buf = appendUvarint(append(buf, binArray), uint64(val.Len()))
+
+		for i := 0; i < val.Len(); i++ {
+			var err error
+
+			if buf, err = appendBinValue(buf, val.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("maps keyed by %s can not be encoded", val.Type().Key())
+		}
+
+		if val.IsNil() {
+			return append(buf, binNil), nil
+		}
+
+		buf = appendUvarint(append(buf, binMap), uint64(val.Len()))
+
+		iter := val.MapRange()
+
+		for iter.Next() {
+			var err error
+
+			buf = appendBinString(buf, iter.Key().String())
+
+			if buf, err = appendBinValue(buf, iter.Value()); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+
+	case reflect.Struct:
+		typ := val.Type()
+		names := make([]string, 0, typ.NumField())
+		fields := make([]reflect.Value, 0, typ.NumField())
+
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name, omitEmpty, skip := binFieldName(field)
+			if skip || (omitEmpty && val.Field(i).IsZero()) {
+				continue
+			}
+
+			names = append(names, name)
+			fields = append(fields, val.Field(i))
+		}
+
+		buf = appendUvarint(append(buf, binMap), uint64(len(names)))
+
+		for i, name := range names {
+			var err error
+
+			buf = appendBinString(buf, name)
+
+			if buf, err = appendBinValue(buf, fields[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+
+	default:
+		return nil, fmt.Errorf("values of kind %s can not be encoded", val.Kind())
+	}
+}
+
+//
+// appendBinString appends the provided string, prefixed by its length, to the provided buffer.
+//
+func appendBinString(buf []byte, str string) []byte {
+	buf = appendUvarint(buf, uint64(len(str)))
+
+	return append(buf, str...)
+}
+
+//
+// appendUvarint appends the provided unsigned integer, varint encoded, to the provided buffer.
+//
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+
+	n := binary.PutUvarint(tmp[:], v)
+
+	return append(buf, tmp[:n]...)
+}
+
+//
+// appendVarint appends the provided signed integer, zig-zag varint encoded, to the provided buffer.
+//
+func appendVarint(buf []byte, v int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+
+	n := binary.PutVarint(tmp[:], v)
+
+	return append(buf, tmp[:n]...)
+}
+
+//
+// appendUint64 appends the provided unsigned integer, as eight little-endian bytes, to the provided
+// buffer.
+//
+func appendUint64(buf []byte, v uint64) []byte {
+	var tmp [8]byte
+
+	binary.LittleEndian.PutUint64(tmp[:], v)
+
+	return append(buf, tmp[:]...)
+}
+
+//
+// binFieldName works out the name that a struct field should be encoded under, honoring the same
+// "json" struct tags that the JSON codec does.
+//
+func binFieldName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name := field.Name
+
+	if len(parts[0]) > 0 {
+		name = parts[0]
+	}
+
+	omitEmpty := false
+
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+//
+// readBinValue decodes a single value from the front of the provided buffer, returning it along
+// with whatever remains of the buffer.
+//
+func readBinValue(buf []byte, depth int) (interface{}, []byte, error) {
+	if depth > binMaxDepth {
+		return nil, nil, errors.New("values are nested too deeply")
+	}
+
+	if len(buf) == 0 {
+		return nil, nil, errors.New("unexpected end of message")
+	}
+
+	tag, buf := buf[0], buf[1:]
+
+	switch tag {
+	case binNil:
+		return nil, buf, nil
+
+	case binFalse:
+		return false, buf, nil
+
+	case binTrue:
+		return true, buf, nil
+
+	case binInt:
+		i, n := binary.Varint(buf)
+		if n <= 0 {
+			return nil, nil, errors.New("malformed integer")
+		}
+
+		return i, buf[n:], nil
+
+	case binFloat:
+		if len(buf) < 8 {
+			return nil, nil, errors.New("malformed float")
+		}
+
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf)), buf[8:], nil
+
+	case binString:
+		return readBinString(buf)
+
+	case binArray:
+		count, n := binary.Uvarint(buf)
+		if n <= 0 || count > uint64(len(buf)) {
+			return nil, nil, errors.New("malformed array length")
+		}
+
+		buf = buf[n:]
+		arr := make([]interface{}, 0, count)
+
+		for i := uint64(0); i < count; i++ {
+			var elem interface{}
+			var err error
+
+			if elem, buf, err = readBinValue(buf, depth+1); err != nil {
+				return nil, nil, err
+			}
+
+			arr = append(arr, elem)
+		}
+
+		return arr, buf, nil
+
+	case binMap:
+		count, n := binary.Uvarint(buf)
+		if n <= 0 || count > uint64(len(buf)) {
+			return nil, nil, errors.New("malformed map length")
+		}
+
+		buf = buf[n:]
+		m := make(map[string]interface{}, count)
+
+		for i := uint64(0); i < count; i++ {
+			var key, elem interface{}
+			var err error
+
+			if key, buf, err = readBinString(buf); err != nil {
+				return nil, nil, err
+			}
+
+			if elem, buf, err = readBinValue(buf, depth+1); err != nil {
+				return nil, nil, err
+			}
+
+			m[key.(string)] = elem
+		}
+
+		return m, buf, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown value tag %d", tag)
+	}
+}
+
+//
+// readBinString decodes a single length-prefixed string from the front of the provided buffer,
+// returning it along with whatever remains of the buffer.
+//
+func readBinString(buf []byte) (interface{}, []byte, error) {
+	length, n := binary.Uvarint(buf)
+	if n <= 0 || length > uint64(len(buf)-n) {
+		return nil, nil, errors.New("malformed string")
+	}
+
+	buf = buf[n:]
+
+	return string(buf[:length]), buf[length:], nil
+}
+
+//
+// cobsEncode applies consistent overhead byte stuffing to the provided bytes so that the result
+// contains no zero bytes.
+//
+func cobsEncode(src []byte) []byte {
+	dst := make([]byte, 1, len(src)+len(src)/254+2)
+	codeIdx := 0
+	code := byte(1)
+
+	for _, b := range src {
+		if b != 0 {
+			dst = append(dst, b)
+			code++
+		}
+
+		if b == 0 || code == 0xFF {
+			dst[codeIdx] = code
+			codeIdx = len(dst)
+			dst = append(dst, 0)
+			code = 1
+		}
+	}
+
+	dst[codeIdx] = code
+
+	return dst
+}
+
+//
+// cobsDecode reverses consistent overhead byte stuffing.
+//
+func cobsDecode(src []byte) ([]byte, error) {
+	dst := make([]byte, 0, len(src))
+
+	for i := 0; i < len(src); {
+		code := src[i]
+		if code == 0 {
+			return nil, errors.New("unexpected zero byte in stuffed message")
+		}
+
+		i++
+
+		end := i + int(code) - 1
+		if end > len(src) {
+			return nil, errors.New("stuffed message is truncated")
+		}
+
+		dst = append(dst, src[i:end]...)
+		i = end
+
+		if code != 0xFF && i < len(src) {
+			dst = append(dst, 0)
+		}
+	}
+
+	return dst, nil
+}
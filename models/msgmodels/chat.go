@@ -25,6 +25,22 @@ const (
 	// ChatColSys is the color of system-related (e.g. command help) messages.
 	//
 	ChatColSys = "system"
+
+	//
+	// ChatChanArea is the channel of messages that reach everybody in the sender's area. Chat
+	// messages that do not specify a channel are sent to it.
+	//
+	ChatChanArea = "area"
+
+	//
+	// ChatChanGlobal is the channel of messages that reach everybody on the server.
+	//
+	ChatChanGlobal = "global"
+
+	//
+	// ChatChanParty is the channel of messages that reach everybody in the sender's party.
+	//
+	ChatChanParty = "party"
 )
 
 //
@@ -35,4 +51,5 @@ type Chat struct {
 	Author  string
 	Content string
 	Color   string
+	Channel string `json:",omitempty"`
 }
@@ -0,0 +1,94 @@
+package msgmodels
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	//
+	// CodecJSON is the name of the JSON codec. All connections start out using it.
+	//
+	CodecJSON = "json"
+
+	//
+	// CodecBinary is the name of the compact binary codec.
+	//
+	CodecBinary = "binary"
+)
+
+//
+// Codec provides a generic interface for the different wire formats that messages can be
+// serialized into and deserialized from.
+//
+// NOTE: Encoded messages must never contain a NUL byte, as that is what the transport uses to
+//  delimit messages.
+//
+type Codec interface {
+	//
+	// Name provides the name (e.g. "json") that clients use to select the codec.
+	//
+	Name() string
+
+	//
+	// Encode serializes the provided message.
+	//
+	Encode(msg *Msg) ([]byte, error)
+
+	//
+	// Decode deserializes the provided raw message. Data payloads are always decoded into generic
+	// maps, slices, and primitives so that handlers can decode them further.
+	//
+	Decode(raw []byte) (*Msg, error)
+}
+
+//
+// LookupCodec retrieves the codec with the specified name.
+//
+func LookupCodec(name string) (Codec, bool) {
+	switch name {
+	case CodecJSON:
+		return JSONCodec{}, true
+	case CodecBinary:
+		return BinaryCodec{}, true
+	default:
+		return nil, false
+	}
+}
+
+//
+// JSONCodec is a codec that serializes messages as JSON. It is verbose, but it is very easy to
+// debug.
+//
+type JSONCodec struct{}
+
+//
+// Name implements the method defined by the Codec interface.
+//
+func (o JSONCodec) Name() string {
+	return CodecJSON
+}
+
+//
+// Encode implements the method defined by the Codec interface.
+//
+func (o JSONCodec) Encode(msg *Msg) ([]byte, error) {
+	return msg.JSON()
+}
+
+//
+// Decode implements the method defined by the Codec interface.
+//
+func (o JSONCodec) Decode(raw []byte) (*Msg, error) {
+	msg := new(Msg)
+
+	if err := json.Unmarshal(raw, msg); err != nil {
+		return nil, err
+	}
+
+	if len(msg.Key) == 0 {
+		return nil, errors.New("the message has no key")
+	}
+
+	return msg, nil
+}
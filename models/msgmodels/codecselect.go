@@ -0,0 +1,10 @@
+package msgmodels
+
+//
+// CodecSelect represents the data payload of a "CodecSelect"-type message. Clients send it to ask
+// the server to switch the wire format of their connection, and the server sends it back (still in
+// the old wire format) to say which format will be used from then on.
+//
+type CodecSelect struct {
+	Name string // The name of the codec (e.g. "binary") to use.
+}
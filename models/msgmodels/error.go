@@ -0,0 +1,47 @@
+package msgmodels
+
+const (
+	//
+	// ErrCodeUnknownKey indicates that no handler is known for the request's message type key.
+	//
+	ErrCodeUnknownKey = "unknownKey"
+
+	//
+	// ErrCodeAuthRequired indicates that the request can only be handled once the client has
+	// authenticated.
+	//
+	ErrCodeAuthRequired = "authRequired"
+
+	//
+	// ErrCodeBadPayload indicates that the request's data payload is malformed (e.g. it has unknown
+	// fields or values of the wrong type).
+	//
+	ErrCodeBadPayload = "badPayload"
+
+	//
+	// ErrCodeRateLimited indicates that the client has sent too many messages of the request's type
+	// too quickly.
+	//
+	ErrCodeRateLimited = "rateLimited"
+
+	//
+	// ErrCodeInternal indicates that something unexpected went wrong on the server while handling
+	// the request.
+	//
+	ErrCodeInternal = "internal"
+
+	//
+	// ErrCodeHandlerFailed indicates that the handler for the request failed to process it.
+	//
+	ErrCodeHandlerFailed = "handlerFailed"
+)
+
+//
+// Error represents the data payload of an "Error"-type message, which the server sends in reply to
+// a request that could not be handled. The message carries the same identifier as the request.
+//
+type Error struct {
+	Code    string // A machine-readable code (e.g. "authRequired") categorizing the error.
+	Message string // A human-readable explanation of the error.
+	Key     string // The message type key of the request that could not be handled.
+}
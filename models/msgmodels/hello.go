@@ -0,0 +1,39 @@
+package msgmodels
+
+const (
+	//
+	// ProtocolVersion is the newest version of the protocol that the server speaks. It must be bumped
+	// whenever a change is made that existing clients would not understand.
+	//
+	ProtocolVersion = 1
+
+	//
+	// MinProtocolVersion is the oldest version of the protocol that the server still speaks.
+	//
+	MinProtocolVersion = 1
+)
+
+const (
+	//
+	// CapBinaryCodec is the capability of switching a connection over to the binary codec by way of a
+	// "CodecSelect"-type message.
+	//
+	CapBinaryCodec = "binaryCodec"
+
+	//
+	// CapUDP is the capability of sending object synchronizations in UDP datagrams after requesting a
+	// session with a "UDPBind"-type message.
+	//
+	CapUDP = "udp"
+)
+
+//
+// Hello represents the data payload of a "Hello"-type message. Clients must send it before doing
+// anything else (including authenticating) to say which version of the protocol they speak and
+// which optional capabilities they support. The server sends it back with its own protocol version
+// and the capabilities that both sides support.
+//
+type Hello struct {
+	ProtocolVersion int      // The version of the protocol spoken by the sender.
+	Capabilities    []string // The optional capabilities (e.g. "udp") supported by the sender.
+}
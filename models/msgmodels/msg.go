@@ -9,10 +9,15 @@ import (
 // Msg represents a generic message that contains a command key (e.g. "ping", or "sendChatMessage")
 // and a map of the remaining message payload for use by the appropriate handler implementation.
 //
+// Clients may optionally give a message an identifier of their choosing. Any reply that the server
+// sends in direct response to the message (including an "Error"-type message) carries the same
+// identifier so that the client can tell which request it belongs to.
+//
 // NOTE: We intentionally make all members of this class public to help with both serialization and
 //  with logging.
 //
 type Msg struct {
+	ID   string `json:",omitempty"`
 	Key  string
 	Data interface{}
 }
@@ -29,6 +34,17 @@ func CreateMsg(data interface{}) *Msg {
 	return msg
 }
 
+//
+// CreateReply constructs a new message instance that is a reply to the provided request message,
+// meaning that it carries the same identifier.
+//
+func CreateReply(request *Msg, data interface{}) *Msg {
+	msg := CreateMsg(data)
+	msg.ID = request.ID
+
+	return msg
+}
+
 //
 // JSON serializes the message.
 //
@@ -0,0 +1,10 @@
+package msgmodels
+
+//
+// ObjDestroy represents the structure of a message that tells clients to destroy their instance of a
+// synchronized object.
+//
+type ObjDestroy struct {
+  ObjectID string // The unique ID of the object instance to destroy.
+  AreaID   string // The unique ID of the area that the object was in.
+}
@@ -0,0 +1,11 @@
+package msgmodels
+
+//
+// ObjSyncBatch represents the structure of a message that bundles up multiple object
+// synchronizations for the same area into a single frame. Each contained synchronization only
+// carries the variables that have changed since the recipient was last told about the object.
+//
+type ObjSyncBatch struct {
+  AreaID string     // The unique ID of the area that all of the synchronized objects exist in.
+  Syncs  []*ObjSync // The individual object synchronizations.
+}
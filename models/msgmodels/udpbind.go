@@ -0,0 +1,12 @@
+package msgmodels
+
+//
+// UDPBind represents the data payload of a "UDPBind"-type message. Authenticated clients send it
+// (with empty fields) over their primary connection to ask for a UDP session, and the server sends
+// it back with the session token that must accompany every datagram the client sends to the UDP
+// port.
+//
+type UDPBind struct {
+	Token string // The session token that binds datagrams to the client's primary connection.
+	Port  int    // The UDP port that the server is listening for datagrams on.
+}
@@ -0,0 +1,15 @@
+package msgmodels
+
+//
+// UDPObjSync represents the data payload of a "UDPObjSync"-type message. It is only ever sent by
+// clients in UDP datagrams, and carries the same information as an "ObjSync"-type message along
+// with what is needed to tie the datagram to a client and to discard datagrams that arrive out of
+// order.
+//
+type UDPObjSync struct {
+	Token     string                 // The session token handed out in the client's "UDPBind"-type message.
+	Seq       int64                  // Sequence number that must increase with every datagram the client sends.
+	ObjectID  string                 // The unique ID of the object instance to synchronize.
+	AreaID    string                 // The unique ID of the area that the object exists in.
+	Variables map[string]interface{} // The actual payload of variables to synchronize.
+}
@@ -0,0 +1,12 @@
+package msgmodels
+
+//
+// Whisper represents the data payload of a private chat message between two players. Clients send
+// it with the recipient's username as the target, and the server delivers it to the recipient (and
+// echoes it back to the sender) with the author filled in.
+//
+type Whisper struct {
+	Author  string // The username of the player that sent the whisper. Ignored when sent by clients.
+	Target  string // The username of the player that the whisper is for.
+	Content string // The actual text of the whisper.
+}
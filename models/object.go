@@ -7,6 +7,11 @@ type Object interface {
   //
   ObjectID() string
 
+  //
+  // Provides the name of the object as it is known to clients (e.g. "oPlayer").
+  //
+  Type() string
+
   //
   // Provides the identifier of the area that the object resides in.
   //
@@ -0,0 +1,207 @@
+package anticheatservice
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/util"
+)
+
+const (
+	//
+	// speedGraceSecs is a bit of extra time that is credited to every movement check so that network
+	// jitter (e.g. two position updates arriving back-to-back) does not look like a speed violation.
+	//
+	speedGraceSecs = 0.25
+
+	//
+	// maxMoveSecs is the most time that a single movement check credits since the previous accepted
+	// position (a few ticks' worth), so that a client that stands still for a while can not bank the
+	// time and then teleport across the area in one go.
+	//
+	maxMoveSecs = 0.25
+)
+
+var (
+	o    *AntiCheatService
+	once sync.Once
+)
+
+//
+// AntiCheatService represents an instance of the Anti-Cheat Service, which is responsible for
+// validating that what clients claim to be doing is actually possible.
+//
+type AntiCheatService struct {
+	config *Config // Structure with the service's configuration parameters.
+}
+
+//
+// Config represents a struct of configuration settings for the Anti-Cheat Service.
+//
+type Config struct {
+	MaxSpeed            float64 // Maximum distance that a player character may move per second.
+	ViolationThreshold  int     // Number of violations within the violation window after which a client gets kicked. Zero disables kicking.
+	ViolationWindowSecs int     // How long each violation counts against a client. Zero for forever.
+}
+
+//
+// Instance provides a singleton instance of the service.
+//
+func Instance() *AntiCheatService {
+	once.Do(func() {
+		o = &AntiCheatService{
+			config: &Config{},
+		}
+	})
+
+	return o
+}
+
+//
+// Config allows for the Anti-Cheat Service to be configured. It is up to the caller to execute this
+// method before any clients are able to connect. Failing to do so may result in a corrupt program
+// state.
+//
+func (o *AntiCheatService) Config(config *Config) {
+	o.config = config
+}
+
+//
+// ValidateObjSync checks that the provided synchronization request from the provided client is
+// legitimate. Coordinates that fall outside of the client's area are clamped in place (and the
+// client is told where it actually is). If the request is not legitimate, a violation is recorded
+// against the client and an error is returned explaining why – unless the violation got the client
+// kicked, in which case nil is returned since there is nobody left to explain anything to.
+//
+func (o *AntiCheatService) ValidateObjSync(client *models.Client, data *msgmodels.ObjSync) error {
+	//
+	// Clients may only synchronize the objects that they own.
+	//
+	if data.ObjectID != client.ObjectID() {
+		return o.violation(client, fmt.Sprintf("Attempted to synchronize unowned object %s.", data.ObjectID))
+	}
+
+	//
+	// Clients may only synchronize their object within the area that the server knows it to be in.
+	// Changing areas has its own message.
+	//
+	if len(data.AreaID) > 0 && data.AreaID != client.AreaID() {
+		return o.violation(client, fmt.Sprintf("Attempted to synchronize into area %s.", data.AreaID))
+	}
+
+	data.AreaID = client.AreaID()
+
+	//
+	// If the position did not change, there is nothing else to check.
+	//
+	lastX, lastY, lastAt := client.LastValidPosition()
+
+	x, hasX := util.GetIntVal(data.Variables[models.SyncVarX])
+	y, hasY := util.GetIntVal(data.Variables[models.SyncVarY])
+
+	if !hasX && !hasY {
+		return nil
+	}
+
+	if !hasX {
+		x = lastX
+	}
+
+	if !hasY {
+		y = lastY
+	}
+
+	//
+	// Keep the position within the bounds of the area.
+	//
+	clampedX, clampedY := x, y
+
+	if area, prs := gameserverservice.Instance().Area(client.AreaID()); prs {
+		clampedX, clampedY = area.Clamp(x, y)
+	}
+
+	//
+	// Make sure the object did not move faster than it possibly could have.
+	//
+	now := time.Now()
+
+	if o.config.MaxSpeed > 0 {
+		dist := math.Hypot(float64(clampedX-lastX), float64(clampedY-lastY))
+		elapsed := math.Min(now.Sub(lastAt).Seconds(), maxMoveSecs)
+		allowed := o.config.MaxSpeed * (elapsed + speedGraceSecs)
+
+		if dist > allowed {
+			o.correct(client, lastX, lastY)
+
+			return o.violation(
+				client,
+				fmt.Sprintf("Moved %.0f units when only %.0f were possible.", dist, allowed),
+			)
+		}
+	}
+
+	//
+	// Accept the (possibly clamped) position. If it had to be clamped, the client needs to be told
+	// where it actually is.
+	//
+	if hasX {
+		data.Variables[models.SyncVarX] = clampedX
+	}
+
+	if hasY {
+		data.Variables[models.SyncVarY] = clampedY
+	}
+
+	if clampedX != x || clampedY != y {
+		o.correct(client, clampedX, clampedY)
+	}
+
+	client.SetLastValidPosition(clampedX, clampedY, now)
+
+	return nil
+}
+
+//
+// violation records a violation with the provided explanation against the provided client, kicking
+// it if it has racked up too many recently. An error explaining the violation is returned, unless
+// the client was kicked.
+//
+func (o *AntiCheatService) violation(client *models.Client, explanation string) error {
+	violations := client.IncViolations(time.Duration(o.config.ViolationWindowSecs) * time.Second)
+
+	log.Printf(
+		"%sAnti-cheat violation recorded. (Violations: %d) (Explanation: %s)",
+		client.LogPrefix(), violations, explanation,
+	)
+
+	if o.config.ViolationThreshold > 0 && violations >= o.config.ViolationThreshold {
+		gameserverservice.Instance().Kick(client, "Too many anti-cheat violations.")
+
+		return nil
+	}
+
+	return errors.New(explanation)
+}
+
+//
+// correct tells the provided client that its object is actually at the specified location.
+//
+func (o *AntiCheatService) correct(client *models.Client, x int, y int) {
+	msg := msgmodels.CreateMsg(&msgmodels.ObjSync{
+		ObjectID: client.ObjectID(),
+		AreaID:   client.AreaID(),
+		Variables: map[string]interface{}{
+			models.SyncVarX: x,
+			models.SyncVarY: y,
+		},
+	})
+
+	gameserverservice.Instance().SendMessage(client, msg)
+}
@@ -0,0 +1,74 @@
+package authservice
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	o    *AuthService
+	once sync.Once
+)
+
+//
+// AuthService represents an instance of the Auth Service, which is responsible for verifying the
+// tokens that clients present when authenticating.
+//
+type AuthService struct {
+	config *Config // Structure with the service's configuration parameters.
+}
+
+//
+// Config represents a struct of configuration settings for the Auth Service.
+//
+type Config struct {
+	Verifier    TokenVerifier // The backend used to actually verify tokens.
+	MaxFailures int           // Number of failed authentication attempts after which a client gets disconnected.
+}
+
+//
+// Instance provides a singleton instance of the service.
+//
+func Instance() *AuthService {
+	once.Do(func() {
+		o = &AuthService{}
+	})
+
+	return o
+}
+
+//
+// Config allows for the Auth Service to be configured. It is up to the caller to execute this
+// method before any clients are able to connect. Failing to do so may result in a corrupt program
+// state.
+//
+func (o *AuthService) Config(config *Config) {
+	o.config = config
+}
+
+//
+// Verify checks the provided token against the configured verifier backend.
+//
+func (o *AuthService) Verify(token string) (*Claims, error) {
+	if o.config == nil || o.config.Verifier == nil {
+		return nil, errors.New("no token verifier has been configured")
+	}
+
+	if len(token) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	return o.config.Verifier.Verify(token)
+}
+
+//
+// MaxFailures returns the number of failed authentication attempts after which a client should be
+// disconnected.
+//
+func (o *AuthService) MaxFailures() int {
+	if o.config == nil || o.config.MaxFailures <= 0 {
+		return 1
+	}
+
+	return o.config.MaxFailures
+}
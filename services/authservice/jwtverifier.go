@@ -0,0 +1,187 @@
+package authservice
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"strings"
+	"time"
+)
+
+//
+// clockSkew is the amount of leeway given when checking the time-based claims of a token, so that
+// slight clock drift between the issuer and this server does not cause spurious failures.
+//
+const clockSkew = 30 * time.Second
+
+//
+// JWTVerifier is a token verifier that validates HMAC-signed JSON Web Tokens entirely offline. The
+// player ID is taken from the "sub" claim, the username from the "name" claim, and any roles granted
+// to the player from the "roles" claim (an array of strings).
+//
+type JWTVerifier struct {
+	secret   []byte // Shared secret that tokens are signed with by the issuer.
+	audience string // The audience that tokens must have been issued for. Not checked if empty.
+}
+
+//
+// jwtHeader represents the header segment of a JSON Web Token.
+//
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+//
+// jwtPayload represents the (relevant subset of the) claims segment of a JSON Web Token.
+//
+type jwtPayload struct {
+	Sub   string          `json:"sub"`
+	Name  string          `json:"name"`
+	Roles []string        `json:"roles"`
+	Aud   json.RawMessage `json:"aud"`
+	Exp   *int64          `json:"exp"`
+	Nbf   *int64          `json:"nbf"`
+}
+
+//
+// CreateJWTVerifier constructs a new JWT verifier that accepts tokens signed with the provided
+// secret and issued for the provided audience.
+//
+func CreateJWTVerifier(secret []byte, audience string) *JWTVerifier {
+	return &JWTVerifier{
+		secret:   secret,
+		audience: audience,
+	}
+}
+
+//
+// Verify implements the method defined by the TokenVerifier interface.
+//
+func (o *JWTVerifier) Verify(token string) (*Claims, error) {
+	//
+	// Split the token into its three segments.
+	//
+	segs := strings.Split(token, ".")
+	if len(segs) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	//
+	// Decode the header and make sure we understand the signing algorithm. Anything other than the
+	// HMAC family (most importantly "none") is rejected outright.
+	//
+	var header jwtHeader
+
+	if err := decodeJWTSegment(segs[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var hashFunc func() hash.Hash
+
+	switch header.Alg {
+	case "HS256":
+		hashFunc = sha256.New
+	case "HS384":
+		hashFunc = sha512.New384
+	case "HS512":
+		hashFunc = sha512.New
+	default:
+		return nil, ErrInvalidToken
+	}
+
+	//
+	// Check the signature before trusting anything in the payload.
+	//
+	sig, err := base64.RawURLEncoding.DecodeString(segs[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	mac := hmac.New(hashFunc, o.secret)
+	mac.Write([]byte(segs[0] + "." + segs[1]))
+
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, ErrInvalidToken
+	}
+
+	//
+	// Decode the payload and validate its claims.
+	//
+	var payload jwtPayload
+
+	if err := decodeJWTSegment(segs[1], &payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if len(payload.Sub) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+
+	if payload.Exp == nil || now.After(time.Unix(*payload.Exp, 0).Add(clockSkew)) {
+		return nil, ErrExpiredToken
+	}
+
+	if payload.Nbf != nil && now.Add(clockSkew).Before(time.Unix(*payload.Nbf, 0)) {
+		return nil, ErrExpiredToken
+	}
+
+	if len(o.audience) > 0 && !audienceContains(payload.Aud, o.audience) {
+		return nil, ErrWrongAudience
+	}
+
+	return &Claims{
+		PlayerID:  payload.Sub,
+		Username:  payload.Name,
+		Roles:     payload.Roles,
+		ExpiresAt: time.Unix(*payload.Exp, 0),
+	}, nil
+}
+
+//
+// decodeJWTSegment decodes a single base64url-encoded JSON segment of a token into the provided
+// structure.
+//
+func decodeJWTSegment(seg string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+//
+// audienceContains checks whether the raw "aud" claim (which may be either a single string or an
+// array of strings) contains the expected audience.
+//
+func audienceContains(raw json.RawMessage, expected string) bool {
+	if len(raw) == 0 {
+		return false
+	}
+
+	var single string
+
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == expected
+	}
+
+	var multiple []string
+
+	if err := json.Unmarshal(raw, &multiple); err != nil {
+		return false
+	}
+
+	for _, aud := range multiple {
+		if aud == expected {
+			return true
+		}
+	}
+
+	return false
+}
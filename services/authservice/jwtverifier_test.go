@@ -0,0 +1,139 @@
+package authservice
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//
+// signJWT builds a token carrying the provided payload whose header names the provided algorithm,
+// signed with the provided secret using the matching HMAC hash. Algorithms outside of the HMAC
+// family get an empty signature.
+//
+func signJWT(t *testing.T, alg string, secret string, payload map[string]interface{}) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		t.Fatalf("Failed to encode the header. (Error: %s)", err)
+	}
+
+	claims, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Failed to encode the payload. (Error: %s)", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(claims)
+
+	hashFuncs := map[string]func() hash.Hash{
+		"HS256": sha256.New,
+		"HS384": sha512.New384,
+		"HS512": sha512.New,
+	}
+
+	hashFunc, prs := hashFuncs[alg]
+	if !prs {
+		return signingInput + "."
+	}
+
+	mac := hmac.New(hashFunc, []byte(secret))
+	mac.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTVerifierVerify(t *testing.T) {
+	verifier := CreateJWTVerifier([]byte("secret"), "arcane")
+	now := time.Now().Unix()
+
+	//
+	// claims returns a valid payload for player "p1" with the provided claims merged into it. Claims
+	// with nil values are dropped from it.
+	//
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		payload := map[string]interface{}{
+			"sub":   "p1",
+			"name":  "Alice",
+			"roles": []string{"moderator"},
+			"aud":   "arcane",
+			"exp":   now + 60,
+		}
+
+		for key, val := range overrides {
+			if val == nil {
+				delete(payload, key)
+			} else {
+				payload[key] = val
+			}
+		}
+
+		return payload
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected error
+	}{
+		{"HS256", signJWT(t, "HS256", "secret", claims(nil)), nil},
+		{"HS384", signJWT(t, "HS384", "secret", claims(nil)), nil},
+		{"HS512", signJWT(t, "HS512", "secret", claims(nil)), nil},
+		{"bad signature", signJWT(t, "HS256", "not the secret", claims(nil)), ErrInvalidToken},
+		{"alg none", signJWT(t, "none", "", claims(nil)), ErrInvalidToken},
+		{"unexpected alg", signJWT(t, "RS256", "secret", claims(nil)), ErrInvalidToken},
+		{"not a JWT", "not.a.jwt", ErrInvalidToken},
+		{"too few segments", "abc.def", ErrInvalidToken},
+		{"missing sub", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"sub": nil})), ErrInvalidToken},
+		{"missing exp", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"exp": nil})), ErrExpiredToken},
+		{"expired", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"exp": now - 3600})), ErrExpiredToken},
+		{"expired within skew", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"exp": now - 20})), nil},
+		{"expired beyond skew", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"exp": now - 40})), ErrExpiredToken},
+		{"nbf in the past", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"nbf": now - 60})), nil},
+		{"nbf within skew", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"nbf": now + 20})), nil},
+		{"nbf beyond skew", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"nbf": now + 40})), ErrExpiredToken},
+		{"nbf in the future", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"nbf": now + 3600})), ErrExpiredToken},
+		{"aud in array", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"aud": []string{"other", "arcane"}})), nil},
+		{"wrong aud", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"aud": "other"})), ErrWrongAudience},
+		{"wrong aud in array", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"aud": []string{"other"}})), ErrWrongAudience},
+		{"missing aud", signJWT(t, "HS256", "secret", claims(map[string]interface{}{"aud": nil})), ErrWrongAudience},
+	}
+
+	for _, test := range tests {
+		actual, err := verifier.Verify(test.token)
+		if err != test.expected {
+			t.Errorf("Unexpected verification result. (Test: %s) (Expected: %v) (Actual: %v)", test.name, test.expected, err)
+
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if actual.PlayerID != "p1" || actual.Username != "Alice" || !reflect.DeepEqual(actual.Roles, []string{"moderator"}) {
+			t.Errorf("Unexpected claims. (Test: %s) (Claims: %+v)", test.name, *actual)
+		}
+	}
+}
+
+func TestJWTVerifierIgnoresAudienceWhenUnset(t *testing.T) {
+	verifier := CreateJWTVerifier([]byte("secret"), "")
+
+	token := signJWT(t, "HS256", "secret", map[string]interface{}{
+		"sub": "p1",
+		"aud": "anybody",
+		"exp": time.Now().Unix() + 60,
+	})
+
+	if _, err := verifier.Verify(token); err != nil {
+		t.Errorf("Expected the audience to go unchecked. (Error: %s)", err)
+	}
+}
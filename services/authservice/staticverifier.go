@@ -0,0 +1,93 @@
+package authservice
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//
+// StaticTokenVerifier is a token verifier backed by a flat file of pre-shared tokens. It is only
+// intended to be used for local development, where standing up a real token issuer is overkill.
+//
+// Each non-empty line of the file that does not begin with "#" should be of the form
+// "{token} {playerID} [{username} [{roles}]]", where "{roles}" is a comma-separated list of the
+// roles (e.g. "moderator,admin") granted to the player. Empty entries in the list are ignored.
+//
+type StaticTokenVerifier struct {
+	tokens map[string]*Claims // Table of known tokens and the claims that they map to.
+}
+
+//
+// CreateStaticTokenVerifier constructs a new static token verifier by loading the tokens in the
+// file at the provided path.
+//
+func CreateStaticTokenVerifier(path string) (*StaticTokenVerifier, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	o := &StaticTokenVerifier{
+		tokens: make(map[string]*Claims),
+	}
+
+	scanner := bufio.NewScanner(file)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 4 {
+			return nil, fmt.Errorf("malformed token entry on line %d of \"%s\"", lineNum, path)
+		}
+
+		claims := &Claims{
+			PlayerID: fields[1],
+		}
+
+		if len(fields) >= 3 {
+			claims.Username = fields[2]
+		}
+
+		if len(fields) == 4 {
+			for _, role := range strings.Split(fields[3], ",") {
+				if len(role) > 0 {
+					claims.Roles = append(claims.Roles, role)
+				}
+			}
+		}
+
+		o.tokens[fields[0]] = claims
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+//
+// Verify implements the method defined by the TokenVerifier interface.
+//
+func (o *StaticTokenVerifier) Verify(token string) (*Claims, error) {
+	claims, prs := o.tokens[token]
+	if !prs {
+		return nil, ErrInvalidToken
+	}
+
+	//
+	// Hand back a copy so that callers can not tamper with the table.
+	//
+	claimsCopy := *claims
+	claimsCopy.Roles = append([]string(nil), claims.Roles...)
+
+	return &claimsCopy, nil
+}
@@ -0,0 +1,118 @@
+package authservice
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+//
+// writeTokenFile writes the provided contents to a temporary token file, returning its path. It is
+// up to the caller to remove it.
+//
+func writeTokenFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	file, err := ioutil.TempFile("", "tokens")
+	if err != nil {
+		t.Fatalf("Failed to create the token file. (Error: %s)", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(contents); err != nil {
+		t.Fatalf("Failed to write the token file. (Error: %s)", err)
+	}
+
+	return file.Name()
+}
+
+func TestStaticTokenVerifierVerify(t *testing.T) {
+	path := writeTokenFile(t, ""+
+		"# Comments and blank lines are ignored.\n"+
+		"\n"+
+		"token1 p1\n"+
+		"token2 p2 Bob\n"+
+		"  token3   p3   Carol   moderator,admin  \n"+
+		"token4 p4 Dave ,moderator,,\n"+
+		"token5 p5 Eve ,\n",
+	)
+	defer os.Remove(path)
+
+	verifier, err := CreateStaticTokenVerifier(path)
+	if err != nil {
+		t.Fatalf("Failed to load the token file. (Error: %s)", err)
+	}
+
+	tests := []struct {
+		token    string
+		expected *Claims
+	}{
+		{"token1", &Claims{PlayerID: "p1"}},
+		{"token2", &Claims{PlayerID: "p2", Username: "Bob"}},
+		{"token3", &Claims{PlayerID: "p3", Username: "Carol", Roles: []string{"moderator", "admin"}}},
+		{"token4", &Claims{PlayerID: "p4", Username: "Dave", Roles: []string{"moderator"}}},
+		{"token5", &Claims{PlayerID: "p5", Username: "Eve"}},
+		{"token6", nil},
+		{"p1", nil},
+		{"#", nil},
+	}
+
+	for _, test := range tests {
+		actual, err := verifier.Verify(test.token)
+
+		if test.expected == nil {
+			if err != ErrInvalidToken {
+				t.Errorf("Expected the token to be rejected. (Token: %s) (Error: %v)", test.token, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Failed to verify the token. (Token: %s) (Error: %s)", test.token, err)
+
+			continue
+		}
+
+		if actual.PlayerID != test.expected.PlayerID || actual.Username != test.expected.Username ||
+			len(actual.Roles)+len(test.expected.Roles) > 0 && !reflect.DeepEqual(actual.Roles, test.expected.Roles) {
+			t.Errorf("Unexpected claims. (Token: %s) (Expected: %+v) (Actual: %+v)", test.token, *test.expected, *actual)
+		}
+	}
+}
+
+func TestStaticTokenVerifierHandsOutCopies(t *testing.T) {
+	path := writeTokenFile(t, "token1 p1 Alice moderator\n")
+	defer os.Remove(path)
+
+	verifier, err := CreateStaticTokenVerifier(path)
+	if err != nil {
+		t.Fatalf("Failed to load the token file. (Error: %s)", err)
+	}
+
+	claims, _ := verifier.Verify("token1")
+	claims.Roles[0] = "admin"
+
+	if claims, _ := verifier.Verify("token1"); claims.Roles[0] != "moderator" {
+		t.Errorf("Expected the token table to be unaffected by callers. (Roles: %q)", claims.Roles)
+	}
+}
+
+func TestStaticTokenVerifierRejectsMalformedLines(t *testing.T) {
+	tests := []string{
+		"token1\n",
+		"token1 p1 Alice moderator extra\n",
+		"token1 p1\ntoken2\n",
+	}
+
+	for _, contents := range tests {
+		path := writeTokenFile(t, contents)
+
+		if _, err := CreateStaticTokenVerifier(path); err == nil {
+			t.Errorf("Expected the token file to be rejected. (Contents: %q)", contents)
+		}
+
+		os.Remove(path)
+	}
+}
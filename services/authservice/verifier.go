@@ -0,0 +1,47 @@
+package authservice
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	//
+	// ErrInvalidToken is returned by token verifiers when a token is malformed, unknown, or carries a
+	// bad signature.
+	//
+	ErrInvalidToken = errors.New("the token is invalid")
+
+	//
+	// ErrExpiredToken is returned by token verifiers when a token is well-formed but no longer (or not
+	// yet) valid.
+	//
+	ErrExpiredToken = errors.New("the token has expired or is not yet valid")
+
+	//
+	// ErrWrongAudience is returned by token verifiers when a token was not issued for this server.
+	//
+	ErrWrongAudience = errors.New("the token was not issued for this audience")
+)
+
+//
+// Claims represents the verified facts about a player that have been extracted from a token.
+//
+type Claims struct {
+	PlayerID  string    // The unique identifier of the player that the token was issued to.
+	Username  string    // The display name of the player, if the token carried one.
+	Roles     []string  // The roles (e.g. "moderator") granted to the player, beyond the one that every player holds.
+	ExpiresAt time.Time // When the token stops being valid. Zero if the token never expires.
+}
+
+//
+// TokenVerifier provides a generic interface for backends that are able to turn an opaque token
+// (as provided by a client in an "Auth"-type message) into a verified set of claims.
+//
+type TokenVerifier interface {
+	//
+	// Verify checks the provided token and, if it is valid, returns the claims that it carries. If it
+	// is not valid, an error explaining why is returned instead.
+	//
+	Verify(token string) (*Claims, error)
+}
@@ -0,0 +1,332 @@
+package chatfilterservice
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	//
+	// ModeMask causes filtered words to be masked out (e.g. "****") while the rest of the message is
+	// still sent.
+	//
+	ModeMask = "mask"
+
+	//
+	// ModeReject causes messages containing filtered words to not be sent at all.
+	//
+	ModeReject = "reject"
+
+	//
+	// maskRune is the character that each character of a filtered word is replaced with.
+	//
+	maskRune = '*'
+)
+
+var (
+	o    *ChatFilterService
+	once sync.Once
+)
+
+var (
+	//
+	// ErrEmpty is returned when a message has nothing left in it once it has been sanitized.
+	//
+	ErrEmpty = errors.New("the message is empty")
+
+	//
+	// ErrFiltered is returned when a message contains a filtered word and the filter is configured to
+	// reject such messages.
+	//
+	ErrFiltered = errors.New("the message contains language that is not allowed")
+
+	//
+	// leetRunes maps characters that are commonly substituted for letters (i.e. "leetspeak") to the
+	// letters that they stand in for. See leetDigits for the subset that are digits.
+	//
+	leetRunes = map[rune]rune{
+		'0': 'o',
+		'1': 'i',
+		'3': 'e',
+		'4': 'a',
+		'5': 's',
+		'7': 't',
+		'8': 'b',
+		'9': 'g',
+		'@': 'a',
+		'$': 's',
+		'!': 'i',
+		'|': 'l',
+		'+': 't',
+	}
+
+	//
+	// leetDigits is the subset of leetRunes that are digits.
+	//
+	leetDigits = func() map[rune]rune {
+		digits := make(map[rune]rune)
+
+		for r, leet := range leetRunes {
+			if unicode.IsDigit(r) {
+				digits[r] = leet
+			}
+		}
+
+		return digits
+	}()
+)
+
+//
+// ChatFilterService represents an instance of the Chat Filter Service, which sanitizes the content
+// of chat messages before they are sent on to other players.
+//
+type ChatFilterService struct {
+	mu       *sync.RWMutex    // Mutex to protect against concurrent access to the configuration and compiled word patterns.
+	config   *Config          // Structure with the service's configuration parameters.
+	patterns []*regexp.Regexp // Compiled patterns matching each filtered word. See compileWord().
+}
+
+//
+// Config represents a struct of configuration settings for the Chat Filter Service.
+//
+type Config struct {
+	MaxLength int      // Maximum number of characters that a sanitized message may have. Zero for no limit.
+	Words     []string // Words that are not allowed in messages. Leetspeak variants are caught as well.
+	Mode      string   // What happens to messages containing filtered words. See the "Mode*" constants.
+}
+
+//
+// Instance provides a singleton instance of the service. Until it is configured, messages are
+// sanitized but no words are filtered and there is no length limit.
+//
+func Instance() *ChatFilterService {
+	once.Do(func() {
+		o = &ChatFilterService{
+			mu:     &sync.RWMutex{},
+			config: &Config{},
+		}
+	})
+
+	return o
+}
+
+//
+// Config allows for the Chat Filter Service to be configured.
+//
+func (o *ChatFilterService) Config(config *Config) {
+	patterns := make([]*regexp.Regexp, 0, len(config.Words))
+
+	for _, word := range config.Words {
+		if pattern := compileWord(word); pattern != nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.config = config
+	o.patterns = patterns
+}
+
+//
+// LoadWordList reads the words that are not allowed in messages from the file at the provided path.
+// The file should contain one word (or phrase, such as "bad word") per line. Blank lines and lines
+// starting with "#" are ignored.
+//
+func LoadWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	words := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		words = append(words, line)
+	}
+
+	return words, scanner.Err()
+}
+
+//
+// Sanitize runs the provided message content through the sanitization pipeline and returns the
+// result. The content is Unicode-normalized, stripped of control (and other invisible formatting)
+// characters, and has its whitespace collapsed. It is then checked against the maximum length and
+// the filtered words. If the message should not be sent at all, an error explaining why (that is
+// suitable for showing to the sender) is returned.
+//
+func (o *ChatFilterService) Sanitize(content string) (string, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	//
+	// Normalize the content so that visually identical text is made up of identical characters
+	// (e.g. full-width letters become regular ones) and can not be used to sneak past the filter.
+	//
+	content = norm.NFKC.String(content)
+
+	//
+	// Strip control and formatting characters (e.g. zero-width spaces and bidirectional overrides),
+	// and collapse all runs of whitespace (including newlines) down to single spaces.
+	//
+	content = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			return -1
+		default:
+			return r
+		}
+	}, content)
+
+	content = strings.Join(strings.Fields(content), " ")
+
+	if len(content) == 0 {
+		return "", ErrEmpty
+	}
+
+	//
+	// Enforce the maximum length, counting characters rather than bytes.
+	//
+	runes := []rune(content)
+
+	if o.config.MaxLength > 0 && len(runes) > o.config.MaxLength {
+		return "", fmt.Errorf("the message is longer than %d characters", o.config.MaxLength)
+	}
+
+	//
+	// Look for filtered words in simplified "skeletons" of the content, and either mask them out or
+	// reject the message outright. Symbols like "!" are just as often punctuation as they are
+	// leetspeak, and digits are just as often part of a word as they are leetspeak, so words are
+	// looked for with all, only digit, and no leetspeak standing in for letters.
+	//
+	filtered := false
+
+	for _, leet := range []map[rune]rune{leetRunes, leetDigits, nil} {
+		skeleton := skeletonize(runes, leet)
+
+		for _, pattern := range o.patterns {
+			for _, match := range pattern.FindAllStringIndex(skeleton, -1) {
+				filtered = true
+
+				for i := match[0]; i < match[1]; i++ {
+					runes[i] = maskRune
+				}
+			}
+		}
+	}
+
+	if filtered && o.config.Mode == ModeReject {
+		return "", ErrFiltered
+	}
+
+	return string(runes), nil
+}
+
+//
+// skeletonize reduces the provided characters to a string with exactly one byte per character, in
+// which letters (and the provided leetspeak characters that stand in for them) are lowercase ASCII
+// letters, other letters are underscores, and everything else is a space. This lets filtered words
+// be matched by simple patterns whose match positions line up with the original characters.
+//
+// Leetspeak characters are only treated as letters when they are part of a run of characters that
+// contains a real letter (e.g. the "3" in "h3llo") or that is made up entirely of leetspeak symbols
+// (e.g. "@$$"), so that plain numbers (e.g. "455") are left alone.
+//
+func skeletonize(runes []rune, leet map[rune]rune) string {
+	skeleton := make([]byte, len(runes))
+
+	for start := 0; start < len(runes); {
+		//
+		// Find the end of the run of letters and leetspeak characters starting here (if any), noting
+		// whether or not it contains a real letter or a digit.
+		//
+		end := start
+		letters := false
+		digits := false
+
+		for ; end < len(runes); end++ {
+			if unicode.IsLetter(runes[end]) {
+				letters = true
+			} else if _, prs := leet[runes[end]]; !prs {
+				break
+			} else if unicode.IsDigit(runes[end]) {
+				digits = true
+			}
+		}
+
+		if end == start {
+			end++
+		}
+
+		for i := start; i < end; i++ {
+			r := runes[i]
+
+			if sub, prs := leet[r]; prs && (letters || !digits) {
+				r = sub
+			}
+
+			r = unicode.ToLower(r)
+
+			switch {
+			case r >= 'a' && r <= 'z':
+				skeleton[i] = byte(r)
+			case unicode.IsLetter(r):
+				skeleton[i] = '_'
+			default:
+				skeleton[i] = ' '
+			}
+		}
+
+		start = end
+	}
+
+	return string(skeleton)
+}
+
+//
+// compileWord compiles a pattern that matches the provided filtered word in a skeleton (see
+// skeletonize()) as a whole word, even if some of its letters have been repeated (e.g. "baaad").
+// If the word is actually a phrase (e.g. "bad word"), its words may be separated by any amount of
+// whitespace or punctuation (or none at all). Returns nil if the word has no letters in it.
+//
+func compileWord(word string) *regexp.Regexp {
+	groups := make([]string, 0)
+
+	for _, field := range strings.Fields(skeletonize([]rune(norm.NFKC.String(word)), leetRunes)) {
+		var group strings.Builder
+
+		for _, r := range field {
+			if r >= 'a' && r <= 'z' {
+				group.WriteString(string(r) + "+")
+			}
+		}
+
+		if group.Len() > 0 {
+			groups = append(groups, group.String())
+		}
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	return regexp.MustCompile(`\b` + strings.Join(groups, ` *`) + `\b`)
+}
@@ -0,0 +1,121 @@
+package chatfilterservice
+
+import "testing"
+
+func TestSanitizeMasksFilteredWords(t *testing.T) {
+	svc := Instance()
+	svc.Config(&Config{
+		Words: []string{"hell", "ass", "bad word"},
+		Mode:  ModeMask,
+	})
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"what the hell", "what the ****"},
+		{"what the h3ll!", "what the ****!"},
+		{"what the he||", "what the ****"},
+		{"hello there", "hello there"},
+		{"I scored 455 points", "I scored 455 points"},
+		{"you 455", "you 455"},
+		{"you a55", "you ***"},
+		{"you @$$", "you ***"},
+		{"you 4$$", "you 4$$"},
+		{"wow!!", "wow!!"},
+		{"you a$$", "you ***"},
+		{"that was a bad word", "that was a ********"},
+		{"that was a bad, word", "that was a *********"},
+		{"that was a badword", "that was a *******"},
+		{"that was a bad wordle", "that was a bad wordle"},
+	}
+
+	for _, test := range tests {
+		actual, err := svc.Sanitize(test.content)
+		if err != nil {
+			t.Errorf("Failed to sanitize %q. (Error: %s)", test.content, err)
+
+			continue
+		}
+
+		if actual != test.expected {
+			t.Errorf("Unexpected sanitized content. (Content: %q) (Expected: %q) (Actual: %q)", test.content, test.expected, actual)
+		}
+	}
+}
+
+func TestSanitizeRejectsFilteredWords(t *testing.T) {
+	svc := Instance()
+	svc.Config(&Config{
+		Words: []string{"hell"},
+		Mode:  ModeReject,
+	})
+
+	if _, err := svc.Sanitize("what the h3ll"); err != ErrFiltered {
+		t.Errorf("Expected the message to be rejected. (Error: %v)", err)
+	}
+
+	if actual, err := svc.Sanitize("hello there"); err != nil || actual != "hello there" {
+		t.Errorf("Expected a clean message to be sent as-is. (Actual: %q) (Error: %v)", actual, err)
+	}
+}
+
+func TestSanitizeEnforcesMaxLength(t *testing.T) {
+	svc := Instance()
+	svc.Config(&Config{MaxLength: 5})
+
+	tests := []struct {
+		content string
+		allowed bool
+	}{
+		{"hello", true},
+		{"héllo", true},
+		{"  hello  ", true},
+		{"hello!", false},
+	}
+
+	for _, test := range tests {
+		_, err := svc.Sanitize(test.content)
+
+		if allowed := err == nil; allowed != test.allowed {
+			t.Errorf("Unexpected length check. (Content: %q) (Allowed: %t) (Error: %v)", test.content, test.allowed, err)
+		}
+	}
+}
+
+func TestSanitizeStripsInvisibleCharacters(t *testing.T) {
+	svc := Instance()
+	svc.Config(&Config{
+		Words: []string{"hell"},
+		Mode:  ModeMask,
+	})
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"hi\x07 there", "hi there"},
+		{"hi\u200b there", "hi there"},
+		{"hi \u202eereht", "hi ereht"},
+		{"hi\n\tthere", "hi there"},
+		{"h\u200bell", "****"},
+		{"\uff48\uff45\uff4c\uff4c", "****"},
+	}
+
+	for _, test := range tests {
+		actual, err := svc.Sanitize(test.content)
+		if err != nil {
+			t.Errorf("Failed to sanitize %q. (Error: %s)", test.content, err)
+
+			continue
+		}
+
+		if actual != test.expected {
+			t.Errorf("Unexpected sanitized content. (Content: %q) (Expected: %q) (Actual: %q)", test.content, test.expected, actual)
+		}
+	}
+
+	if _, err := svc.Sanitize("\u200b\x07 \u202e"); err != ErrEmpty {
+		t.Errorf("Expected a message of only invisible characters to be empty. (Error: %v)", err)
+	}
+}
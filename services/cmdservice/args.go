@@ -0,0 +1,58 @@
+package cmdservice
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	//
+	// ErrUsage is returned by argument parsers (and may be returned by commands) when a command has
+	// been invoked incorrectly. The client is shown how the command should be invoked instead.
+	//
+	ErrUsage = errors.New("the command was invoked incorrectly")
+)
+
+//
+// ArgParser represents a function that splits the raw text following a command's name into the
+// arguments that are handed to the command.
+//
+type ArgParser func(raw string) ([]string, error)
+
+//
+// Args returns an argument parser that splits the raw argument text on whitespace into at least min
+// and at most max arguments. The last argument holds all of the remaining text, whitespace and all,
+// so that free-form text (e.g. a message) can be passed as the final argument. ErrUsage is returned
+// if there are too few arguments, or if there is any text at all when max is zero.
+//
+func Args(min int, max int) ArgParser {
+	return func(raw string) ([]string, error) {
+		args := make([]string, 0, max)
+		rest := strings.TrimSpace(raw)
+
+		for len(rest) > 0 && len(args) < max-1 {
+			i := strings.IndexFunc(rest, unicode.IsSpace)
+			if i < 0 {
+				break
+			}
+
+			args = append(args, rest[:i])
+			rest = strings.TrimSpace(rest[i:])
+		}
+
+		if len(rest) > 0 {
+			if len(args) >= max {
+				return nil, ErrUsage
+			}
+
+			args = append(args, rest)
+		}
+
+		if len(args) < min {
+			return nil, ErrUsage
+		}
+
+		return args, nil
+	}
+}
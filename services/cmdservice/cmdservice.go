@@ -0,0 +1,220 @@
+package cmdservice
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/services/playerinfoservice"
+)
+
+const (
+	//
+	// Prefix is the character that chat content must begin with in order to be treated as a command.
+	// Doubling it up (e.g. "//shrug") sends the content as regular chat with one of them removed.
+	//
+	Prefix = "/"
+)
+
+var (
+	o    *CmdService
+	once sync.Once
+)
+
+//
+// CmdService represents an instance of the Command Service, which routes chat content that begins
+// with a slash (e.g. "/help") to registered commands rather than relaying it to other players. This
+// allows gameplay and administrative features to be exposed without new client message types.
+//
+type CmdService struct {
+	mu       *sync.RWMutex       // Mutex to protect against concurrent modification of the command tables.
+	commands map[string]*Command // Table of registered commands keyed by their lowercased name.
+	aliases  map[string]*Command // Table of registered commands keyed by their lowercased aliases.
+}
+
+//
+// Command represents a registered chat command.
+//
+type Command struct {
+	Name    string                                           // The name that the command is invoked by (e.g. "help" for "/help").
+	Aliases []string                                         // Alternative names that the command may also be invoked by.
+	Usage   string                                           // The arguments that the command expects (e.g. "<player> <message>").
+	Summary string                                           // A short, one-line, description of what the command does.
+	Role    string                                           // The role required to invoke the command. Empty if anybody may.
+	Args    ArgParser                                        // Splits the raw argument text into arguments. Defaults to Args(0, 0).
+	Run     func(client *models.Client, args []string) error // The actual function to execute when the command is invoked.
+}
+
+//
+// Instance provides a singleton instance of the service. The built-in "/help" command is always
+// registered.
+//
+func Instance() *CmdService {
+	once.Do(func() {
+		o = &CmdService{
+			mu:       &sync.RWMutex{},
+			commands: make(map[string]*Command),
+			aliases:  make(map[string]*Command),
+		}
+
+		o.RegisterCommand(&Command{
+			Name:    "help",
+			Aliases: []string{"?", "commands"},
+			Usage:   "[command]",
+			Summary: "Lists the commands available to you, or explains how to use one of them.",
+			Args:    Args(0, 1),
+			Run:     o.runHelp,
+		})
+	})
+
+	return o
+}
+
+//
+// RegisterCommand registers the provided command so that it can be invoked from chat by its name or
+// any of its aliases. Registering a command with the same name as an existing one replaces it.
+//
+func (o *CmdService) RegisterCommand(cmd *Command) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.commands[strings.ToLower(cmd.Name)] = cmd
+
+	for _, alias := range cmd.Aliases {
+		o.aliases[strings.ToLower(alias)] = cmd
+	}
+
+	log.Printf("Registered new chat command \"%s%s\".", Prefix, cmd.Name)
+}
+
+//
+// IsCommand checks whether or not the provided chat content is a command invocation rather than
+// regular chat.
+//
+func IsCommand(content string) bool {
+	return strings.HasPrefix(content, Prefix) && !strings.HasPrefix(content, Prefix+Prefix)
+}
+
+//
+// Unescape strips the extra prefix character from chat content that was escaped so as not to be
+// treated as a command invocation (e.g. "//shrug" becomes "/shrug").
+//
+func Unescape(content string) string {
+	if strings.HasPrefix(content, Prefix+Prefix) {
+		return content[len(Prefix):]
+	}
+
+	return content
+}
+
+//
+// Execute invokes the command named by the provided chat content (which must begin with the
+// prefix) on behalf of the provided client. Any problem (e.g. an unknown command, bad arguments, or
+// insufficient permissions) is explained to the client privately.
+//
+func (o *CmdService) Execute(client *models.Client, content string) {
+	//
+	// Split the content into the command name and the raw text of its arguments.
+	//
+	line := strings.TrimSpace(strings.TrimPrefix(content, Prefix))
+	name := line
+	rawArgs := ""
+
+	if i := strings.IndexFunc(line, unicode.IsSpace); i >= 0 {
+		name = line[:i]
+		rawArgs = strings.TrimSpace(line[i:])
+	}
+
+	//
+	// Look up the command and make sure that the client is allowed to invoke it. Commands that the
+	// client is not allowed to invoke are reported as unknown so as not to advertise them.
+	//
+	cmd, prs := o.Command(name)
+	if !prs || !o.Allowed(client, cmd) {
+		Reply(client, fmt.Sprintf("Unknown command \"%s%s\". Try \"%shelp\".", Prefix, name, Prefix))
+
+		return
+	}
+
+	//
+	// Parse the arguments and actually run the command.
+	//
+	parser := cmd.Args
+	if parser == nil {
+		parser = Args(0, 0)
+	}
+
+	args, err := parser(rawArgs)
+	if err == nil {
+		err = cmd.Run(client, args)
+	}
+
+	switch {
+	case err == ErrUsage:
+		Reply(client, fmt.Sprintf("Usage: %s", cmd.Syntax()))
+	case err != nil:
+		Reply(client, err.Error())
+	}
+
+	log.Printf(
+		"%sExecuted chat command \"%s%s\". (Error: %v)",
+		client.LogPrefix(), Prefix, cmd.Name, err,
+	)
+}
+
+//
+// Command looks up a registered command by its name or by one of its aliases.
+//
+func (o *CmdService) Command(name string) (*Command, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	name = strings.ToLower(name)
+
+	if cmd, prs := o.commands[name]; prs {
+		return cmd, true
+	}
+
+	cmd, prs := o.aliases[name]
+
+	return cmd, prs
+}
+
+//
+// Allowed checks whether or not the provided client holds the role required to invoke the provided
+// command. Administrators are allowed to invoke every command.
+//
+func (o *CmdService) Allowed(client *models.Client, cmd *Command) bool {
+	return playerinfoservice.Instance().Authorized(client.PlayerID(), cmd.Role)
+}
+
+//
+// Syntax returns a printable representation of how the command is invoked (e.g. "/help [command]").
+//
+func (o *Command) Syntax() string {
+	if len(o.Usage) == 0 {
+		return Prefix + o.Name
+	}
+
+	return Prefix + o.Name + " " + o.Usage
+}
+
+//
+// Reply privately sends the provided text to the provided client as a system-colored chat message.
+// Intended to be used by commands to respond to whoever invoked them.
+//
+func Reply(client *models.Client, text string) {
+	chatMsgData := &msgmodels.Chat{
+		Author:  "Server",
+		Content: text,
+		Color:   msgmodels.ChatColSys,
+	}
+	chatMsg := msgmodels.CreateMsg(chatMsgData)
+
+	gameserverservice.Instance().SendMessage(client, chatMsg)
+}
@@ -0,0 +1,65 @@
+package cmdservice
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lukehollenback/arcane-server/models"
+)
+
+//
+// runHelp implements the built-in "/help" command. Without arguments, it lists every command that
+// the client is allowed to invoke. Otherwise, it explains how to use the specified command.
+//
+func (o *CmdService) runHelp(client *models.Client, args []string) error {
+	//
+	// Explain a single command if one was asked about.
+	//
+	if len(args) > 0 {
+		name := strings.TrimPrefix(args[0], Prefix)
+
+		cmd, prs := o.Command(name)
+		if !prs || !o.Allowed(client, cmd) {
+			Reply(client, fmt.Sprintf("Unknown command \"%s%s\".", Prefix, name))
+
+			return nil
+		}
+
+		Reply(client, fmt.Sprintf("Usage: %s", cmd.Syntax()))
+		Reply(client, cmd.Summary)
+
+		if len(cmd.Aliases) > 0 {
+			Reply(client, fmt.Sprintf("Aliases: %s%s", Prefix, strings.Join(cmd.Aliases, ", "+Prefix)))
+		}
+
+		return nil
+	}
+
+	//
+	// Otherwise, list every command that the client is allowed to invoke in alphabetical order.
+	//
+	o.mu.RLock()
+
+	cmds := make([]*Command, 0, len(o.commands))
+
+	for _, cmd := range o.commands {
+		cmds = append(cmds, cmd)
+	}
+
+	o.mu.RUnlock()
+
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].Name < cmds[j].Name
+	})
+
+	Reply(client, "Available commands:")
+
+	for _, cmd := range cmds {
+		if o.Allowed(client, cmd) {
+			Reply(client, fmt.Sprintf("%s - %s", cmd.Syntax(), cmd.Summary))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,310 @@
+package gameserverservice
+
+import (
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "log"
+
+  "github.com/lukehollenback/arcane-server/models"
+  "github.com/lukehollenback/arcane-server/models/msgmodels"
+  "github.com/lukehollenback/arcane-server/util"
+)
+
+//
+// LoadAreas reads the definitions of the areas that exist in the game world from the JSON file at
+// the provided path. The file should contain an array of area objects.
+//
+func LoadAreas(path string) ([]*models.Area, error) {
+  raw, err := ioutil.ReadFile(path)
+  if err != nil {
+    return nil, err
+  }
+
+  areas := make([]*models.Area, 0)
+
+  if err := json.Unmarshal(raw, &areas); err != nil {
+    return nil, err
+  }
+
+  return areas, nil
+}
+
+//
+// DefaultAreas provides the area definitions to use when none have been explicitly configured. The
+// default area is unbounded, because its real size is only known to the game client. Areas files
+// should specify sizes so that positions can be clamped to them.
+//
+func DefaultAreas() []*models.Area {
+  return []*models.Area{
+    {
+      ID:         models.DefaultAreaID,
+      SpawnX:     models.DefaultX,
+      SpawnY:     models.DefaultY,
+      SpawnDepth: models.DefaultDepth,
+    },
+  }
+}
+
+//
+// SendAreaMessage sends the provided message to all connected clients that are currently in the
+// specified area except for those specified to be excluded.
+//
+func (o *GameServerService) SendAreaMessage(areaID string, msg *msgmodels.Msg, excludedClientIDs []int) {
+  //
+  // Log the message. Each wire format that is needed will only be serialized once.
+  //
+  cache := make(encodedMsgCache)
+
+  log.Printf("<~>           %-21s <~ %s", "Area "+areaID, cache.encode(msg, msgmodels.JSONCodec{}))
+
+  //
+  // Fire off the raw message to all clients in the area except for those that are excluded.
+  //
+  for _, client := range o.snapshotAreaClients(areaID) {
+    if excludedClientIDs != nil && util.SliceContainsInt(client.ID(), excludedClientIDs) {
+      continue
+    }
+
+    client.Send(cache.encode(msg, client.Codec()))
+  }
+}
+
+//
+// MoveClientToArea updates the area registry to reflect that the provided client has left the
+// specified previous area and is now in the area that it currently claims to be in.
+//
+func (o *GameServerService) MoveClientToArea(client *models.Client, prevAreaID string) {
+  // NOTE: We must lock because we are going to mutate the area registry. Multiple goroutines may be
+  //  attempting to do the same around the same time.
+
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  o.forgetClientInAreaLocked(client, prevAreaID)
+  o.addClientToAreaLocked(client)
+}
+
+//
+// snapshotAreaClients returns a copy of the clients registered as being in the specified area that
+// can be safely iterated over without holding the area registry's lock.
+//
+func (o *GameServerService) snapshotAreaClients(areaID string) []*models.Client {
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  clients := make([]*models.Client, 0, len(o.areas[areaID]))
+
+  for _, client := range o.areas[areaID] {
+    clients = append(clients, client)
+  }
+
+  return clients
+}
+
+//
+// snapshotAreaObjects returns a copy of the objects in the objects table that are in the specified
+// area that can be safely iterated over without holding the objects table's lock.
+//
+func (o *GameServerService) snapshotAreaObjects(areaID string) []models.Object {
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  objects := make([]models.Object, 0)
+
+  for _, object := range o.objects {
+    if (*object).AreaID() == areaID {
+      objects = append(objects, *object)
+    }
+  }
+
+  return objects
+}
+
+//
+// addClientToAreaLocked registers the provided client as being in the area that it currently claims
+// to be in. It is up to the caller to hold the service's lock.
+//
+func (o *GameServerService) addClientToAreaLocked(client *models.Client) {
+  areaID := client.AreaID()
+
+  if _, prs := o.areas[areaID]; !prs {
+    o.areas[areaID] = make(map[int]*models.Client)
+  }
+
+  o.areas[areaID][client.ID()] = client
+}
+
+//
+// forgetClientInAreaLocked removes the provided client from the specified area of the area
+// registry. It is up to the caller to hold the service's lock.
+//
+func (o *GameServerService) forgetClientInAreaLocked(client *models.Client, areaID string) {
+  areaClients, prs := o.areas[areaID]
+  if !prs {
+    return
+  }
+
+  delete(areaClients, client.ID())
+
+  if len(areaClients) == 0 {
+    delete(o.areas, areaID)
+  }
+}
+
+//
+// Area looks up the definition of the area with the specified unique identifier.
+//
+func (o *GameServerService) Area(areaID string) (*models.Area, bool) {
+  area, prs := o.areaDefs[areaID]
+
+  return area, prs
+}
+
+//
+// ChangeArea moves the provided client's player character into the specified area at that area's
+// spawn point. Where a player character enters an area is decided by the server rather than by
+// the client, so that changing areas can not be used to teleport past anti-cheat validation.
+//
+func (o *GameServerService) ChangeArea(client *models.Client, areaID string) error {
+  //
+  // Make sure the move actually makes sense.
+  //
+  area, prs := o.Area(areaID)
+  if !prs {
+    return fmt.Errorf("the area \"%s\" does not exist", areaID)
+  }
+
+  if client.AreaID() == areaID {
+    return fmt.Errorf("the client is already in the area \"%s\"", areaID)
+  }
+
+  o.placeClient(client, area, area.SpawnX, area.SpawnY)
+
+  return nil
+}
+
+//
+// RestoreLocation moves the provided client's player character back to where its player left off
+// (e.g. at the end of their previous session). The location is kept within the bounds of the area.
+// If the area no longer exists, the player character is left where it is.
+//
+func (o *GameServerService) RestoreLocation(client *models.Client, areaID string, x int, y int) {
+  area, prs := o.Area(areaID)
+  if !prs {
+    return
+  }
+
+  x, y = area.Clamp(x, y)
+
+  //
+  // Moving into a different area takes the full hand-off. Moving within the same area is just a
+  // matter of synchronizing the player character's new position to everybody in it (including the
+  // client itself).
+  //
+  if area.ID != client.AreaID() {
+    o.placeClient(client, area, x, y)
+
+    return
+  }
+
+  client.SetLocation(area.ID, x, y, client.Depth())
+
+  o.QueueObjSync(&msgmodels.ObjSync{
+    ObjectID: client.ObjectID(),
+    AreaID:   area.ID,
+    Variables: map[string]interface{}{
+      models.SyncVarX: x,
+      models.SyncVarY: y,
+    },
+  }, nil)
+}
+
+//
+// placeClient moves the provided client's player character to the specified location within the
+// provided area. Clients in the area being left are told to destroy the player character, clients
+// in the area being entered are told to create it, and the moving client is told where it ended up
+// and about all of the objects that already exist in its new area.
+//
+func (o *GameServerService) placeClient(client *models.Client, area *models.Area, x int, y int) {
+  prevAreaID := client.AreaID()
+
+  //
+  // Tell everybody in the area being left that the player character is gone.
+  //
+  destroyMsg := msgmodels.CreateMsg(&msgmodels.ObjDestroy{
+    ObjectID: client.ObjectID(),
+    AreaID:   prevAreaID,
+  })
+
+  o.SendAreaMessage(prevAreaID, destroyMsg, []int{client.ID()})
+
+  //
+  // Actually move the player character and update the area registry to match.
+  //
+  client.SetLocation(area.ID, x, y, area.SpawnDepth)
+
+  o.MoveClientToArea(client, prevAreaID)
+
+  //
+  // Whatever the moving client was told about the objects in its old area no longer matters, and
+  // the clients in its new area have never been told anything about it.
+  //
+  o.forgetKnownVarsOfClient(client)
+  o.forgetKnownVarsOfObject(client.ObjectID())
+
+  //
+  // Tell the moving client where it ended up, and then about everything that is already in its new
+  // area.
+  //
+  areaChangeMsg := msgmodels.CreateMsg(&msgmodels.AreaChange{
+    AreaID: client.AreaID(),
+    X:      client.X(),
+    Y:      client.Y(),
+    Depth:  client.Depth(),
+  })
+
+  o.SendMessage(client, areaChangeMsg)
+  o.sendAreaObjects(client)
+
+  //
+  // Tell everybody in the area being entered where to instantiate the player character.
+  //
+  o.SendAreaMessage(area.ID, createObjCreateMsg(client, nil), []int{client.ID()})
+}
+
+//
+// sendAreaObjects tells the provided client where to instantiate each of the objects (other than
+// its own player character) that exist in the area that it is in.
+//
+func (o *GameServerService) sendAreaObjects(client *models.Client) {
+  for _, object := range o.snapshotAreaObjects(client.AreaID()) {
+    if object.ObjectID() == client.ObjectID() {
+      continue
+    }
+
+    o.SendMessage(client, createObjCreateMsg(object, client))
+  }
+}
+
+//
+// createObjCreateMsg generates an "ObjCreate"-type message for the provided object as it should be
+// seen by the provided viewing client. If no viewer is provided, the message is generated as it
+// should be seen by clients other than the object's owner.
+//
+func createObjCreateMsg(object models.Object, viewer *models.Client) *msgmodels.Msg {
+  objType := object.Type()
+
+  if objType == models.ObjTypePlayer && (viewer == nil || viewer.ObjectID() != object.ObjectID()) {
+    objType = models.ObjTypeOtherPlayer
+  }
+
+  return msgmodels.CreateMsg(&msgmodels.ObjCreate{
+    Type:     objType,
+    ObjectID: object.ObjectID(),
+    AreaID:   object.AreaID(),
+    X:        object.X(),
+    Y:        object.Y(),
+    Depth:    object.Depth(),
+  })
+}
@@ -0,0 +1,289 @@
+package gameserverservice
+
+import (
+  "errors"
+  "log"
+  "sort"
+  "strings"
+
+  "github.com/lukehollenback/arcane-server/models"
+  "github.com/lukehollenback/arcane-server/models/msgmodels"
+  "github.com/lukehollenback/arcane-server/services/playerinfoservice"
+)
+
+var (
+  //
+  // ErrUnknownChatChannel is returned when a chat channel that does not exist is referenced.
+  //
+  ErrUnknownChatChannel = errors.New("no such chat channel exists")
+
+  //
+  // ErrChatChannelNotOptIn is returned when attempting to join or leave a chat channel that
+  // everybody is implicitly a member of (e.g. area chat).
+  //
+  ErrChatChannelNotOptIn = errors.New("the chat channel can not be joined or left")
+
+  //
+  // ErrChatChannelForbidden is returned when a client lacks the role required to join or send
+  // messages to a chat channel.
+  //
+  ErrChatChannelForbidden = errors.New("permission to use the chat channel has not been granted")
+
+  //
+  // ErrNotInChatChannel is returned when a client attempts to send a message to (or leave) an opt-in
+  // chat channel that it has not joined.
+  //
+  ErrNotInChatChannel = errors.New("the chat channel has not been joined")
+)
+
+//
+// DefaultChatChannels provides the chat channel definitions to use when none have been explicitly
+// configured.
+//
+func DefaultChatChannels() []*models.ChatChannel {
+  return []*models.ChatChannel{
+    {
+      Name:  msgmodels.ChatChanArea,
+      Scope: models.ChatScopeArea,
+    },
+    {
+      Name:  msgmodels.ChatChanGlobal,
+      Scope: models.ChatScopeGlobal,
+    },
+    {
+      Name:  msgmodels.ChatChanParty,
+      Scope: models.ChatScopeParty,
+    },
+    {
+      Name:  "trade",
+      Scope: models.ChatScopeMembers,
+    },
+    {
+      Name:     "staff",
+      Scope:    models.ChatScopeMembers,
+      JoinRole: playerinfoservice.RoleModerator,
+    },
+  }
+}
+
+//
+// ChatChannels returns the definitions of every chat channel that the provided client is allowed to
+// join (or is implicitly a member of), ordered by name.
+//
+func (o *GameServerService) ChatChannels(client *models.Client) []*models.ChatChannel {
+  channels := make([]*models.ChatChannel, 0, len(o.chatChannels))
+
+  for _, channel := range o.chatChannels {
+    if o.playerAuthorized(client, channel.JoinRole) {
+      channels = append(channels, channel)
+    }
+  }
+
+  sort.Slice(channels, func(i, j int) bool {
+    return channels[i].Name < channels[j].Name
+  })
+
+  return channels
+}
+
+//
+// InChatChannel checks whether or not the provided client receives messages sent to the specified
+// chat channel.
+//
+func (o *GameServerService) InChatChannel(client *models.Client, name string) bool {
+  channel, prs := o.chatChannels[strings.ToLower(name)]
+  if !prs {
+    return false
+  }
+
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  switch channel.Scope {
+  case models.ChatScopeMembers:
+    _, prs = o.chatMembers[channel.Name][client.ID()]
+  case models.ChatScopeParty:
+    _, prs = o.parties[client.ID()]
+  }
+
+  return prs
+}
+
+//
+// JoinChatChannel makes the provided client a member of the specified opt-in chat channel so that
+// it receives the messages sent to it.
+//
+func (o *GameServerService) JoinChatChannel(client *models.Client, name string) error {
+  channel, err := o.optInChatChannel(name)
+  if err != nil {
+    return err
+  }
+
+  if !o.playerAuthorized(client, channel.JoinRole) {
+    return ErrChatChannelForbidden
+  }
+
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  members, prs := o.chatMembers[channel.Name]
+  if !prs {
+    members = make(map[int]*models.Client)
+    o.chatMembers[channel.Name] = members
+  }
+
+  members[client.ID()] = client
+
+  log.Printf("%sJoined chat channel \"%s\".", client.LogPrefix(), channel.Name)
+
+  return nil
+}
+
+//
+// LeaveChatChannel stops the provided client from being a member of the specified opt-in chat
+// channel.
+//
+func (o *GameServerService) LeaveChatChannel(client *models.Client, name string) error {
+  channel, err := o.optInChatChannel(name)
+  if err != nil {
+    return err
+  }
+
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  if _, prs := o.chatMembers[channel.Name][client.ID()]; !prs {
+    return ErrNotInChatChannel
+  }
+
+  delete(o.chatMembers[channel.Name], client.ID())
+
+  log.Printf("%sLeft chat channel \"%s\".", client.LogPrefix(), channel.Name)
+
+  return nil
+}
+
+//
+// SendChatMessage routes the provided chat message from the provided client to everybody that
+// receives messages sent to the chat channel that it specifies (or to area chat if it does not
+// specify one). The channel identifier is normalized in the message that is sent.
+//
+func (o *GameServerService) SendChatMessage(client *models.Client, chat *msgmodels.Chat) error {
+  name := strings.ToLower(chat.Channel)
+  if len(name) == 0 {
+    name = msgmodels.ChatChanArea
+  }
+
+  channel, prs := o.chatChannels[name]
+  if !prs {
+    return ErrUnknownChatChannel
+  }
+
+  if !o.playerAuthorized(client, channel.SendRole) {
+    return ErrChatChannelForbidden
+  }
+
+  if !o.InChatChannel(client, channel.Name) {
+    if channel.Scope == models.ChatScopeParty {
+      return ErrNotInParty
+    }
+
+    return ErrNotInChatChannel
+  }
+
+  chat.Channel = channel.Name
+  msg := msgmodels.CreateMsg(chat)
+
+  switch channel.Scope {
+  case models.ChatScopeArea:
+    o.SendAreaMessage(client.AreaID(), msg, nil)
+  case models.ChatScopeGlobal:
+    o.SendAllMessage(msg, nil)
+  case models.ChatScopeParty:
+    o.sendChatMembersMessage(channel.Name, o.PartyMembers(client), msg)
+  default:
+    o.sendChatMembersMessage(channel.Name, o.snapshotChatMembers(channel.Name), msg)
+  }
+
+  return nil
+}
+
+//
+// sendChatMembersMessage sends the provided message, which was sent to the specified chat channel,
+// to the provided members of the channel.
+//
+func (o *GameServerService) sendChatMembersMessage(
+  name string,
+  members []*models.Client,
+  msg *msgmodels.Msg,
+) {
+  //
+  // Log the message. Each wire format that is needed will only be serialized once.
+  //
+  cache := make(encodedMsgCache)
+
+  log.Printf("<~>           %-21s <~ %s", "Channel "+name, cache.encode(msg, msgmodels.JSONCodec{}))
+
+  //
+  // Fire off the raw message to all members of the channel.
+  //
+  for _, client := range members {
+    client.Send(cache.encode(msg, client.Codec()))
+  }
+}
+
+//
+// snapshotChatMembers returns a copy of the members of the specified opt-in chat channel that can
+// be safely iterated over without holding the membership table's lock.
+//
+func (o *GameServerService) snapshotChatMembers(name string) []*models.Client {
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  clients := make([]*models.Client, 0, len(o.chatMembers[name]))
+
+  for _, client := range o.chatMembers[name] {
+    clients = append(clients, client)
+  }
+
+  return clients
+}
+
+//
+// optInChatChannel looks up the definition of the specified chat channel, making sure that it is
+// one that must be explicitly joined.
+//
+func (o *GameServerService) optInChatChannel(name string) (*models.ChatChannel, error) {
+  channel, prs := o.chatChannels[strings.ToLower(name)]
+  if !prs {
+    return nil, ErrUnknownChatChannel
+  }
+
+  if channel.Scope != models.ChatScopeMembers {
+    return nil, ErrChatChannelNotOptIn
+  }
+
+  return channel, nil
+}
+
+//
+// playerAuthorized checks whether or not the provided client has authenticated as a player that
+// holds the specified role. Everybody holds the empty role.
+//
+func (o *GameServerService) playerAuthorized(client *models.Client, role string) bool {
+  if len(role) == 0 {
+    return true
+  }
+
+  return client.Authed() && playerinfoservice.Instance().Authorized(client.PlayerID(), role)
+}
+
+//
+// forgetClientInChatChannelsLocked removes the provided client from the membership tables of all
+// opt-in chat channels. It is up to the caller to hold the service's lock.
+//
+func (o *GameServerService) forgetClientInChatChannelsLocked(client *models.Client) {
+  for _, members := range o.chatMembers {
+    delete(members, client.ID())
+  }
+}
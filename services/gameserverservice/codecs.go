@@ -0,0 +1,59 @@
+package gameserverservice
+
+import (
+  "log"
+
+  "github.com/lukehollenback/arcane-server/models"
+  "github.com/lukehollenback/arcane-server/models/msgmodels"
+)
+
+//
+// encodedMsgCache holds the serialized forms of a single message keyed by the name of the codec
+// that produced them, so that a message being sent to many clients is only serialized once per
+// wire format.
+//
+type encodedMsgCache map[string][]byte
+
+//
+// encode serializes the provided message with the provided codec, or returns the already
+// serialized form if it has been serialized with that codec before.
+//
+func (o encodedMsgCache) encode(msg *msgmodels.Msg, codec msgmodels.Codec) []byte {
+  if rawMsg, prs := o[codec.Name()]; prs {
+    return rawMsg
+  }
+
+  rawMsg, err := codec.Encode(msg)
+  if err != nil {
+    log.Fatalf(
+      "Failed to serialize message with the \"%s\" codec. (Message: %+v) (Error: %s)",
+      codec.Name(), msg, err,
+    )
+  }
+
+  o[codec.Name()] = rawMsg
+
+  return rawMsg
+}
+
+//
+// sendEncodedMessage logs and then sends the provided message to the provided client, serialized in
+// whichever wire format the client has selected. Any serialized forms of the message that are
+// already in the provided cache are reused.
+//
+func (o *GameServerService) sendEncodedMessage(
+  client *models.Client,
+  msg *msgmodels.Msg,
+  cache encodedMsgCache,
+) {
+  //
+  // Log the message. It is always logged as JSON – regardless of the wire format it is sent in – so
+  // that it can be read when debugging.
+  //
+  log.Printf("%s%s", client.SndLogPrefix(), cache.encode(msg, msgmodels.JSONCodec{}))
+
+  //
+  // Fire off the message to the client.
+  //
+  client.Send(cache.encode(msg, client.Codec()))
+}
@@ -0,0 +1,56 @@
+package gameserverservice
+
+import "net/http"
+
+//
+// TableSizes reports how many entries are left in each of the service's tables that track
+// connected clients, so that tests can make sure that nothing is leaked once clients disconnect.
+// Areas and chat channels are counted by their members, and parties by their members and invitees.
+//
+func (o *GameServerService) TableSizes() map[string]int {
+  sizes := make(map[string]int)
+
+  o.mu.RLock()
+
+  sizes["clients"] = len(o.clients)
+  sizes["objects"] = len(o.objects)
+
+  for _, areaClients := range o.areas {
+    sizes["areas"] += len(areaClients)
+  }
+
+  for _, members := range o.chatMembers {
+    sizes["chatMembers"] += len(members)
+  }
+
+  sizes["parties"] = len(o.parties)
+
+  for _, p := range o.parties {
+    sizes["partyInvites"] += len(p.invited)
+  }
+
+  o.mu.RUnlock()
+
+  o.syncMu.Lock()
+
+  sizes["knownVars"] = len(o.knownVars)
+
+  o.syncMu.Unlock()
+
+  o.udpMu.Lock()
+
+  sizes["udpBindings"] = len(o.udpBindings)
+  sizes["udpTokens"] = len(o.udpTokens)
+
+  o.udpMu.Unlock()
+
+  return sizes
+}
+
+//
+// CheckWSOrigin exposes the origin check that requests to upgrade to a WebSocket connection must
+// pass.
+//
+func (o *GameServerService) CheckWSOrigin(r *http.Request) bool {
+  return o.checkWSOrigin(r)
+}
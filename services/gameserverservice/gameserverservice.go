@@ -1,16 +1,21 @@
 package gameserverservice
 
 import (
-  "encoding/json"
+  "errors"
   "fmt"
   "log"
+  "net"
+  "net/http"
   "strings"
   "sync"
   "time"
 
+  "github.com/gorilla/websocket"
   "github.com/lukehollenback/arcane-server/models"
   "github.com/lukehollenback/arcane-server/models/msgmodels"
   "github.com/lukehollenback/arcane-server/services/msghandlerservice"
+  "github.com/lukehollenback/arcane-server/services/playerinfoservice"
+  "github.com/lukehollenback/arcane-server/transport"
   "github.com/lukehollenback/arcane-server/util"
   "github.com/lukehollenback/packet-server/tcp"
 )
@@ -22,16 +27,53 @@ var (
 
 //
 // GameServerService represents an instance of the Game Server Service, which is responsible for
-// communicated with game clients over TCP/IP and UDP protocols.
+// communicated with game clients over TCP/IP, WebSocket, and UDP protocols.
 //
 type GameServerService struct {
-  mu          *sync.Mutex               // Mutex to protect against concurrent modification of the client table.
-  config      *Config                   // Structure with the service's configuration parameters.
-  tcpServer   *tcp.Server               // Instance of a TCP/IP packet server used for interacting with clients.
-  clients     map[int]*models.Client    // Table of known connected clients keyed by their TCP/IP identifier.
-  objects     map[string]*models.Object // Table of known synchronized objects keyed by their unique object identifier.
-  chHBKill    chan bool                 // Channel that can be used to send a kill signal to the heartbeat watchdog goroutine.
-  chHBStopped chan bool                 // Channel upon which the heartbeat watchdog goroutine will send a signal upon completing its shut-down process.
+  mu            *sync.RWMutex                             // Mutex to protect against concurrent access to the client, object, and area tables.
+  config        *Config                                   // Structure with the service's configuration parameters.
+  tcpServer     *tcp.Server                               // Instance of a TCP/IP packet server used for interacting with clients.
+  wsServer      *http.Server                              // Instance of an HTTP server used for interacting with clients over WebSockets. Nil if disabled.
+  wsUpgrader    *websocket.Upgrader                       // Upgrades incoming HTTP requests to WebSocket connections, checking their origin along the way.
+  wsMu          *sync.Mutex                               // Mutex to protect against concurrent access to the WebSocket connection table and identifier counter.
+  wsConns       map[int]*transport.WSConn                 // Table of open WebSocket connections keyed by their connection identifier.
+  wsNextID      int                                       // The connection identifier that will be assigned to the next WebSocket connection.
+  udpConn       *net.UDPConn                              // UDP socket used for receiving unreliable high-frequency updates from clients. Nil if disabled.
+  udpMu         *sync.Mutex                               // Mutex to protect against concurrent access to the UDP socket and session tables.
+  udpBindings   map[string]*udpBinding                    // Table of UDP sessions keyed by their session token.
+  udpTokens     map[int]string                            // Table of UDP session tokens keyed by the connection identifier of the client they were handed out to.
+  chUDPStopped  chan bool                                 // Channel upon which the UDP reading goroutine will send a signal upon completing its shut-down process.
+  wsWG          *sync.WaitGroup                           // Wait group that tracks the goroutines reading from open WebSocket connections.
+  clients       map[int]*models.Client                    // Table of known connected clients keyed by their connection identifier.
+  objects       map[string]*models.Object                 // Table of known synchronized objects keyed by their unique object identifier.
+  areaDefs      map[string]*models.Area                   // Table of the areas that exist in the game world keyed by their unique area identifier.
+  areas         map[string]map[int]*models.Client         // Registry of the clients in each area keyed by area identifier and then by connection identifier.
+  chatChannels  map[string]*models.ChatChannel            // Table of the chat channels that exist keyed by their unique name.
+  chatMembers   map[string]map[int]*models.Client         // Registry of the members of each opt-in chat channel keyed by channel name and then by connection identifier.
+  parties       map[int]*party                            // Table of the party that each client in one belongs to keyed by connection identifier.
+  syncMu        *sync.Mutex                               // Mutex to protect against concurrent access to the object synchronization queue, known variable table, and byte counters.
+  pendingSyncs  map[string][]*pendingSync                 // Queue of object synchronizations waiting to be flushed keyed by area identifier.
+  knownVars     map[int]map[string]map[string]interface{} // Last synchronized variable values sent to each client keyed by connection identifier and then by object identifier.
+  syncBytesFull int64                                     // Number of bytes that object synchronization would have sent without delta compression and batching.
+  syncBytesSent int64                                     // Number of bytes that object synchronization has actually sent.
+  chSyncKill    chan bool                                 // Channel that can be used to send a kill signal to the object synchronization flushing goroutine.
+  chSyncStopped chan bool                                 // Channel upon which the object synchronization flushing goroutine will send a signal upon completing its shut-down process.
+  chHBKill      chan bool                                 // Channel that can be used to send a kill signal to the heartbeat watchdog goroutine.
+  chHBStopped   chan bool                                 // Channel upon which the heartbeat watchdog goroutine will send a signal upon completing its shut-down process.
+}
+
+//
+// ListeningConnection represents a connection that reports the messages it receives by way of a
+// blocking Listen() method (e.g. transport.WSConn or transport.PipeConn).
+//
+type ListeningConnection interface {
+  models.Connection
+
+  //
+  // Listen passes each message received over the connection to the provided callback until the
+  // connection is closed (by either side).
+  //
+  Listen(onMessage func(msg string))
 }
 
 //
@@ -39,7 +81,14 @@ type GameServerService struct {
 //
 type Config struct {
   TCPAddr                    string
+  WSAddr                     string
+  WSPath                     string
+  WSAllowedOrigins           []string
+  UDPAddr                    string
   ClientHeartbeatTimeoutSecs int
+  SyncFlushIntervalMs        int
+  Areas                      []*models.Area
+  ChatChannels               []*models.ChatChannel
 }
 
 //
@@ -48,7 +97,11 @@ type Config struct {
 func Instance() *GameServerService {
   once.Do(func() {
     o = &GameServerService{
-      mu: &sync.Mutex{},
+      mu:     &sync.RWMutex{},
+      syncMu: &sync.Mutex{},
+      wsMu:   &sync.Mutex{},
+      wsWG:   &sync.WaitGroup{},
+      udpMu:  &sync.Mutex{},
     }
   })
 
@@ -70,11 +123,49 @@ func (o *GameServerService) Config(config *Config) {
 func (o *GameServerService) Start() (<-chan bool, error) {
   log.Printf("The Game Server Service is starting...")
 
+  if o.config.SyncFlushIntervalMs <= 0 {
+    return nil, errors.New("the object synchronization flush interval must be positive")
+  }
+
   //
   // (Re)-initialize some of the service's structures.
   //
   o.clients = make(map[int]*models.Client, 0)
   o.objects = make(map[string]*models.Object, 0)
+  o.areas = make(map[string]map[int]*models.Client, 0)
+  o.areaDefs = make(map[string]*models.Area, len(o.config.Areas))
+
+  for _, area := range o.config.Areas {
+    o.areaDefs[area.ID] = area
+
+    if area.Width <= 0 || area.Height <= 0 {
+      log.Printf(
+        "The area \"%s\" is not fully bounded, so positions within it will not be clamped. "+
+            "(Width: %d) (Height: %d)",
+        area.ID, area.Width, area.Height,
+      )
+    }
+  }
+
+  chatChannels := o.config.ChatChannels
+  if chatChannels == nil {
+    chatChannels = DefaultChatChannels()
+  }
+
+  o.chatChannels = make(map[string]*models.ChatChannel, len(chatChannels))
+  o.chatMembers = make(map[string]map[int]*models.Client)
+  o.parties = make(map[int]*party)
+
+  for _, channel := range chatChannels {
+    o.chatChannels[strings.ToLower(channel.Name)] = channel
+  }
+
+  o.pendingSyncs = make(map[string][]*pendingSync)
+  o.knownVars = make(map[int]map[string]map[string]interface{})
+  o.syncBytesFull = 0
+  o.syncBytesSent = 0
+  o.chSyncKill = make(chan bool)
+  o.chSyncStopped = make(chan bool)
   o.chHBKill = make(chan bool)
   o.chHBStopped = make(chan bool)
 
@@ -85,133 +176,65 @@ func (o *GameServerService) Start() (<-chan bool, error) {
     Address: o.config.TCPAddr,
     Delim:   '\x00',
     OnNewClient: func(tcpClient *tcp.Client) {
-      var msg *msgmodels.Msg
-
-      //
-      // Create a new client instance and add it to the service's client table.
-      //
-      client := models.CreateClient(tcpClient)
-
-      o.addClient(client)
-      o.addObject(client)
-
-      //
-      // Tell the new client where to instantiate itself.
-      //
-      msg = msgmodels.CreateMsg(&msgmodels.ObjCreate{
-        Type:     "oPlayer",
-        ObjectID: client.ObjectID(),
-        AreaID:   client.AreaID(),
-        X:        client.X(),
-        Y:        client.Y(),
-        Depth:    client.Depth(),
-      })
-
-      o.SendMessage(client, msg)
-
-      //
-      // Tell the new client where to instantiate all of the other clients.
-      //
-      for id, otherClient := range o.clients {
-        if id == client.TCPClient().ID() {
-          continue
-        }
-
-        msg = msgmodels.CreateMsg(&msgmodels.ObjCreate{
-          Type:     "oOtherPlayer",
-          ObjectID: otherClient.ObjectID(),
-          AreaID:   otherClient.AreaID(),
-          X:        otherClient.X(),
-          Y:        otherClient.Y(),
-          Depth:    otherClient.Depth(),
-        })
-
-        o.SendMessage(client, msg)
-      }
-
-      //
-      // Tell all the other clients where to instantiate the new client.
-      //
-      msg = msgmodels.CreateMsg(&msgmodels.ObjCreate{
-        Type:     "oOtherPlayer",
-        ObjectID: client.ObjectID(),
-        AreaID:   client.AreaID(),
-        X:        client.X(),
-        Y:        client.Y(),
-        Depth:    client.Depth(),
-      })
-
-      o.SendAllMessage(msg, []int{client.TCPClient().ID()})
+      o.onNewClient(models.CreateClient(transport.CreateTCPConn(tcpClient)))
     },
     OnNewMessage: func(tcpClient *tcp.Client, msg string) {
-      //
-      // Locate the client in the client table and update its "last received message" timestamp.
-      //
-      client := o.clients[tcpClient.ID()]
-
-      client.UpdateLastMsgTimestamp()
-
-      //
-      // Clean the message.
-      //
-      msg = strings.Trim(msg, "\x00")
-
-      //
-      // Deserialize the message. If this fails, it is a bogus message.
-      //
-      // TODO: If too many bogus messages are received from the same client, we should kick that
-      //  client off. Such a scenario could be a potential attack.
-      //
-      var m msgmodels.Msg
-
-      unmarshallErr := json.Unmarshal([]byte(msg), &m)
-      if unmarshallErr != nil {
-        log.Printf(
-          "%sAn error occured while attempting to unmarshal a recieved message. (Message: %s) "+
-              "(Error: %s) (Hint: Are they sending bogus messages?)",
-          tcpClient.RcvLogPrefix(),
-          msg,
-          unmarshallErr,
-        )
+      client := o.client(tcpClient.ID())
+      if client == nil {
+        log.Printf("%sReceived a message from an unknown client.", tcpClient.LogPrefix())
 
         return
       }
 
-      //
-      // Log the unmarshalled messaged (in case we need to go back and debug something).
-      //
-      log.Printf("%s%+v", tcpClient.RcvLogPrefix(), m)
-
-      //
-      // Attempt to execute a registered handler for the message.
-      //
-      handlerErr := msghandlerservice.Instance().ExecuteMsgHandler(client, &m)
-      if handlerErr != nil {
-        log.Printf(
-          "%sCould not handle message type. (Error: %s)",
-          tcpClient.LogPrefix(),
-          handlerErr,
-        )
-      }
+      o.onNewMessage(client, msg)
     },
     OnClientConnectionClosed: func(tcpClient *tcp.Client) {
-      client := o.clients[tcpClient.ID()]
+      client := o.client(tcpClient.ID())
+      if client == nil {
+        return
+      }
 
-      o.forgetClient(tcpClient.ID())
-      o.forgetObject(client.ObjectID())
+      o.onClientConnectionClosed(client)
     },
   })
 
+  //
+  // Start listening for WebSocket connections (if configured to do so).
+  //
+  o.wsServer = nil
+
+  if len(o.config.WSAddr) > 0 {
+    err := o.startWSServer()
+    if err != nil {
+      return nil, err
+    }
+  }
+
+  //
+  // Start listening for UDP datagrams (if configured to do so).
+  //
+  if len(o.config.UDPAddr) > 0 {
+    err := o.startUDPServer()
+    if err != nil {
+      o.stopAuxServers()
+
+      return nil, err
+    }
+  }
+
   //
   // Start listening for connections to the TCP/IP packet server (which will spin up its own
   // goroutine), and start watching connected clients' heartbeats
   //
   chTCPServerStarted, err := o.tcpServer.Start()
   if err != nil {
+    o.stopAuxServers()
+
     return nil, err
   }
 
   go o.monitorClientHeartbeats()
+  go o.flushObjSyncs()
 
   //
   // Return the "started" channel from the TCP/IP server because, in this case, that is the only
@@ -233,6 +256,18 @@ func (o *GameServerService) Stop() (<-chan bool, error) {
 
   <-o.chHBStopped
 
+  //
+  // Kill the object synchronization flushing goroutine. We must wait for it to gracefully stop.
+  //
+  o.chSyncKill <- true
+
+  <-o.chSyncStopped
+
+  //
+  // Kill the WebSocket and UDP servers (if they are running).
+  //
+  o.stopAuxServers()
+
   //
   // Kill the TCP server. We must wait for it to finish gracefully shutdown.
   //
@@ -249,30 +284,24 @@ func (o *GameServerService) Stop() (<-chan bool, error) {
 }
 
 //
-// SendMessage sends the provided message to the provided client.
+// stopAuxServers stops whichever of the optional WebSocket and UDP servers are running. This
+// blocks until all WebSocket connections have been closed.
 //
-func (o *GameServerService) SendMessage(client *models.Client, msg *msgmodels.Msg) {
-  //
-  // Serialize the message.
-  //
-  rawMsg, err := msg.JSON()
-  if err != nil {
-    log.Fatalf(
-      "Failed to serialize message intended for client (%s) into JSON. (Message: %+v) "+
-          "(Error: %s)",
-      client.String(), msg, err,
-    )
+func (o *GameServerService) stopAuxServers() {
+  if o.wsServer != nil {
+    o.stopWSServer()
   }
 
-  //
-  // Log the message.
-  //
-  log.Printf("%s%s", client.SndLogPrefix(), rawMsg)
+  if o.udpConn != nil {
+    o.stopUDPServer()
+  }
+}
 
-  //
-  // Fire off the message to the client.
-  //
-  client.TCPClient().SendBytes(rawMsg)
+//
+// SendMessage sends the provided message to the provided client.
+//
+func (o *GameServerService) SendMessage(client *models.Client, msg *msgmodels.Msg) {
+  o.sendEncodedMessage(client, msg, make(encodedMsgCache))
 }
 
 //
@@ -281,33 +310,25 @@ func (o *GameServerService) SendMessage(client *models.Client, msg *msgmodels.Ms
 //
 func (o *GameServerService) SendAllMessage(msg *msgmodels.Msg, excludedClientIDs []int) {
   //
-  // Serialize the message.
+  // Log the message. Each wire format that is needed will only be serialized once.
   //
-  rawMsg, err := msg.JSON()
-  if err != nil {
-    log.Fatalf(
-      "Failed to serialize message intended for all connected clients into JSON. "+
-          "(Message: %+v) (Error: %s)",
-      msg, err,
-    )
-  }
+  cache := make(encodedMsgCache)
 
-  //
-  // Log the message.
-  //
-  log.Printf("<~>           %-21s <~ %s", "All Connected Clients", rawMsg)
+  log.Printf("<~>           %-21s <~ %s", "All Connected Clients", cache.encode(msg, msgmodels.JSONCodec{}))
 
   //
-  // Fire off the raw message to all connected clients except for those that are excluded.
+  // Fire off the raw message to all connected clients except for those that are excluded. We work
+  // off of a snapshot of the client table so that we are not holding its lock while performing
+  // potentially slow network writes.
   //
   // TODO ~> In the future, we could probably spin off goroutines here to do this even faster.
   //
-  for id, client := range o.clients {
-    if excludedClientIDs != nil && util.SliceContainsInt(id, excludedClientIDs) {
+  for _, client := range o.snapshotClients() {
+    if excludedClientIDs != nil && util.SliceContainsInt(client.ID(), excludedClientIDs) {
       continue
     }
 
-    client.TCPClient().SendBytes(rawMsg)
+    client.Send(cache.encode(msg, client.Codec()))
   }
 }
 
@@ -315,7 +336,7 @@ func (o *GameServerService) SendAllMessage(msg *msgmodels.Msg, excludedClientIDs
 // Kick forcefully disconnects the specified client and sends a message to the game world stating
 // the specified reason for the kick.
 //
-func (o *GameServerService) kick(client *models.Client, reason string) {
+func (o *GameServerService) Kick(client *models.Client, reason string) {
   //
   // Send a message to the world explaining that the client is being kicked.
   //
@@ -329,7 +350,18 @@ func (o *GameServerService) kick(client *models.Client, reason string) {
   o.SendAllMessage(chatMsg, nil)
 
   //
-  // Send a disconnect message to the client being kicked.
+  // Actually disconnect the client.
+  //
+  o.Disconnect(client, reason)
+}
+
+//
+// Disconnect sends a disconnect message explaining the specified reason to the specified client,
+// and then forcefully closes its connection. Unlike a kick, the rest of the game world is not told.
+//
+func (o *GameServerService) Disconnect(client *models.Client, reason string) {
+  //
+  // Send a disconnect message to the client being disconnected.
   //
   discMsgData := &msgmodels.Disc{
     Reason: reason,
@@ -341,7 +373,147 @@ func (o *GameServerService) kick(client *models.Client, reason string) {
   //
   // Actually disconnect the client.
   //
-  client.TCPClient().Close()
+  client.Close()
+}
+
+//
+// Serve adds a client communicating over the provided connection to the game world, handles each
+// message that it sends, and then removes it from the game world once the connection has closed. It
+// blocks until then, so it is intended to be run in the goroutine that owns the connection. This
+// allows transports other than the built-in TCP/IP packet server (e.g. WebSockets, or in-memory
+// pipes in tests) to be plugged in.
+//
+func (o *GameServerService) Serve(conn ListeningConnection) {
+  client := models.CreateClient(conn)
+
+  o.onNewClient(client)
+
+  conn.Listen(func(msg string) {
+    o.onNewMessage(client, msg)
+  })
+
+  o.onClientConnectionClosed(client)
+}
+
+//
+// onNewClient adds a newly connected client to the game world. It is called by every transport
+// (e.g. TCP/IP or WebSocket) that clients can connect over.
+//
+func (o *GameServerService) onNewClient(client *models.Client) {
+  //
+  // Add the client to the service's client, area, and object tables.
+  //
+  o.addClient(client)
+
+  //
+  // Tell the new client where to instantiate itself, and then where to instantiate all of the other
+  // objects in its area.
+  //
+  o.SendMessage(client, createObjCreateMsg(client, client))
+  o.sendAreaObjects(client)
+
+  //
+  // Tell all the other clients in the area where to instantiate the new client.
+  //
+  o.SendAreaMessage(client.AreaID(), createObjCreateMsg(client, nil), []int{client.ID()})
+}
+
+//
+// onNewMessage deserializes and handles a raw message that has been received from a client. It is
+// called by every transport (e.g. TCP/IP or WebSocket) that clients can connect over.
+//
+func (o *GameServerService) onNewMessage(client *models.Client, msg string) {
+  //
+  // Update the client's "last received message" timestamp.
+  //
+  client.UpdateLastMsgTimestamp()
+
+  //
+  // Clean the message.
+  //
+  msg = strings.Trim(msg, "\x00")
+
+  //
+  // Deserialize the message using whichever wire format the client has selected. If this fails, it
+  // is a bogus message.
+  //
+  // TODO: If too many bogus messages are received from the same client, we should kick that client
+  //  off. Such a scenario could be a potential attack.
+  //
+  m, unmarshallErr := client.Codec().Decode([]byte(msg))
+  if unmarshallErr != nil {
+    log.Printf(
+      "%sAn error occured while attempting to unmarshal a recieved message. (Codec: %s) "+
+          "(Message: %q) (Error: %s) (Hint: Are they sending bogus messages?)",
+      client.RcvLogPrefix(),
+      client.Codec().Name(),
+      msg,
+      unmarshallErr,
+    )
+
+    return
+  }
+
+  //
+  // Log the unmarshalled messaged (in case we need to go back and debug something).
+  //
+  log.Printf("%s%+v", client.RcvLogPrefix(), *m)
+
+  //
+  // Attempt to execute a registered handler for the message. Any failure is reported back to the
+  // client (and logged, if the logging middleware is in use) by the message handler service.
+  //
+  o.executeMsgHandler(client, m)
+}
+
+//
+// executeMsgHandler hands the provided message off to the Message Handler Service to be handled on
+// behalf of the provided client. Only one message from a given client is handled at a time,
+// whichever transport it arrived over, so that handlers (e.g. anti-cheat validation) can rely on the
+// client's state not changing out from under them.
+//
+func (o *GameServerService) executeMsgHandler(client *models.Client, msg *msgmodels.Msg) {
+  client.LockHandling()
+  defer client.UnlockHandling()
+
+  msghandlerservice.Instance().ExecuteMsgHandler(client, msg)
+}
+
+//
+// onClientConnectionClosed removes a client whose connection has closed from the game world. It is
+// called by every transport (e.g. TCP/IP or WebSocket) that clients can connect over.
+//
+func (o *GameServerService) onClientConnectionClosed(client *models.Client) {
+  o.forgetClient(client)
+  o.forgetUDPBinding(client)
+  o.forgetKnownVarsOfClient(client)
+  o.ForgetObject(client.ObjectID())
+
+  //
+  // Let everybody know that the player has left, and remember where the player left off so that
+  // they can pick back up there next time.
+  //
+  if client.Authed() {
+    chatUsername := playerinfoservice.Instance().GetUsername(client.PlayerID())
+    chatContent := fmt.Sprintf("Farewell, %s!", chatUsername)
+    chatData := &msgmodels.Chat{
+      Author:  "Server",
+      Content: chatContent,
+      Color:   msgmodels.ChatColSvr,
+    }
+    chatMsg := msgmodels.CreateMsg(chatData)
+
+    o.SendAllMessage(chatMsg, nil)
+
+    err := playerinfoservice.Instance().UpdatePlayer(client.PlayerID(), func(player *playerinfoservice.Player) {
+      player.LastAreaID = client.AreaID()
+      player.LastX = client.X()
+      player.LastY = client.Y()
+    })
+    if err != nil {
+      log.Printf("%sFailed to update the player's record. (Error: %s)", client.LogPrefix(), err)
+    }
+  }
 }
 
 //
@@ -370,72 +542,175 @@ func (o *GameServerService) monitorClientHeartbeats() {
 // within the last minute.
 //
 func (o *GameServerService) kickTimedOutClients() {
-  // NOTE: We must lock because we are going to scroll through, and possibly mutate, the client
-  //  table. There may be multiple goroutines attempting to modify the client table around the same
-  //  time that this is occurring.
-
-  o.mu.Lock()
-  defer o.mu.Unlock()
+  // NOTE: We scroll through a snapshot of the client table rather than the table itself. Kicking a
+  //  client sends messages to (and eventually removes the client from) the client table, so the
+  //  table's lock must not be held while doing so.
 
   cutoff := time.Now().Add(-60 * time.Second)
 
   log.Printf("Checking for clients that have not beat their heart since before %s...", cutoff)
 
-  for _, client := range o.clients {
+  for _, client := range o.snapshotClients() {
     if client.LastMsgTimestamp().Before(cutoff) {
-      o.kick(client, "No message received in the last minute.")
+      o.Kick(client, "No message received in the last minute.")
+    }
+  }
+}
+
+//
+// client looks up the client with the specified connection identifier in the client table. Returns
+// nil if no such client is known.
+//
+func (o *GameServerService) client(id int) *models.Client {
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  return o.clients[id]
+}
+
+//
+// FindClientByPlayerID looks up the authenticated client of the player with the specified player ID
+// in the client table.
+//
+func (o *GameServerService) FindClientByPlayerID(playerID string) (*models.Client, bool) {
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  for _, client := range o.clients {
+    if client.Authed() && client.PlayerID() == playerID {
+      return client, true
     }
   }
+
+  return nil, false
 }
 
 //
-// addClient adds the provided client to the client table.
+// Object looks up the object with the specified unique identifier in the objects table.
+//
+func (o *GameServerService) Object(id string) (models.Object, bool) {
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  object, prs := o.objects[id]
+  if !prs {
+    return nil, false
+  }
+
+  return *object, true
+}
+
+//
+// HasObject checks whether or not an object with the specified unique identifier is currently in
+// the objects table.
+//
+func (o *GameServerService) HasObject(id string) bool {
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  _, prs := o.objects[id]
+
+  return prs
+}
+
+//
+// snapshotClients returns a copy of the contents of the client table that can be safely iterated
+// over without holding the table's lock.
+//
+func (o *GameServerService) snapshotClients() []*models.Client {
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  clients := make([]*models.Client, 0, len(o.clients))
+
+  for _, client := range o.clients {
+    clients = append(clients, client)
+  }
+
+  return clients
+}
+
+//
+// addClient adds the provided client to the client table, to the area registry, and (since clients
+// are themselves synchronized objects) to the objects table in one fell swoop.
 //
 func (o *GameServerService) addClient(client *models.Client) {
-  // NOTE: We must lock because we are going to mutate the client table. Multiple goroutines may be
-  //  attempting to do the same around the same time.
+  // NOTE: We must lock because we are going to mutate the client, area, and object tables. Multiple
+  //  goroutines may be attempting to do the same around the same time.
 
   o.mu.Lock()
   defer o.mu.Unlock()
 
-  o.clients[client.TCPClient().ID()] = client
+  o.clients[client.ID()] = client
+  o.addClientToAreaLocked(client)
+
+  var object models.Object = client
+
+  o.objects[client.ObjectID()] = &object
 }
 
 //
-// addObject adds the provided object to the objects table.
+// AddObject adds the provided object to the objects table and tells all clients in the area that it
+// is in where to instantiate it.
 //
-func (o *GameServerService) addObject(object models.Object) {
+func (o *GameServerService) AddObject(object models.Object) {
   // NOTE: We must lock because we are going to mutate the objects table. Multiple goroutines may be
-  //  attempting to do the same around the same time.
+  //  attempting to do the same around the same time. We must NOT still be holding the lock when we
+  //  tell the area about it, though.
 
   o.mu.Lock()
-  defer o.mu.Unlock()
 
   o.objects[object.ObjectID()] = &object
+
+  o.mu.Unlock()
+
+  o.SendAreaMessage(object.AreaID(), createObjCreateMsg(object, nil), nil)
 }
 
 //
-// forgetClient removes the provided client from the clients table.
+// forgetClient removes the provided client from the clients table, from the area registry, from
+// every opt-in chat channel, and from its party.
 //
-func (o *GameServerService) forgetClient(id int) {
-  // NOTE: We must lock because we are going to mutate the client table. Multiple goroutines may be
-  //  attempting to do the same around the same time.
+func (o *GameServerService) forgetClient(client *models.Client) {
+  // NOTE: We must lock because we are going to mutate the client and area tables. Multiple
+  //  goroutines may be attempting to do the same around the same time.
 
   o.mu.Lock()
   defer o.mu.Unlock()
 
-  delete(o.clients, id)
+  delete(o.clients, client.ID())
+  o.forgetClientInAreaLocked(client, client.AreaID())
+  o.forgetClientInChatChannelsLocked(client)
+  o.forgetClientInPartiesLocked(client)
 }
 
 //
-// forgetObject removes the provided object from the objects table.
+// ForgetObject removes the provided object from the objects table and tells all clients in the
+// area that it was in to destroy their instance of it.
 //
-func (o *GameServerService) forgetObject(id string) {
+func (o *GameServerService) ForgetObject(id string) {
   // NOTE: We must lock because we are going to mutate the objects table. Multiple goroutines may be
-  //  attempting to do the same around the same time.
+  //  attempting to do the same around the same time. We must NOT still be holding the lock when we
+  //  tell the area about it, though.
 
   o.mu.Lock()
-  defer o.mu.Unlock()
+
+  object, prs := o.objects[id]
 
   delete(o.objects, id)
+
+  o.mu.Unlock()
+
+  if !prs {
+    return
+  }
+
+  o.forgetKnownVarsOfObject(id)
+
+  destroyMsg := msgmodels.CreateMsg(&msgmodels.ObjDestroy{
+    ObjectID: id,
+    AreaID:   (*object).AreaID(),
+  })
+
+  o.SendAreaMessage((*object).AreaID(), destroyMsg, nil)
 }
@@ -0,0 +1,325 @@
+package gameserverservice_test
+
+import (
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "log"
+  "net"
+  "os"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+
+  "github.com/lukehollenback/arcane-server/handlers"
+  "github.com/lukehollenback/arcane-server/models"
+  "github.com/lukehollenback/arcane-server/models/msgmodels"
+  "github.com/lukehollenback/arcane-server/services/authservice"
+  "github.com/lukehollenback/arcane-server/services/gameserverservice"
+  "github.com/lukehollenback/arcane-server/services/playerinfoservice"
+  "github.com/lukehollenback/arcane-server/services/worldservice"
+  "github.com/lukehollenback/arcane-server/transport"
+)
+
+const (
+  //
+  // stressClients is the number of clients that connect at the same time in the stress test.
+  //
+  stressClients = 200
+
+  //
+  // stressSyncs is the number of object synchronizations that each client sends over its primary
+  // connection (and again over UDP) in the stress test.
+  //
+  stressSyncs = 10
+)
+
+//
+// rcvMsg represents a message received by a test client, with its data payload left raw until the
+// test knows what type of message it is.
+//
+type rcvMsg struct {
+  Key  string
+  Data json.RawMessage
+}
+
+func TestConcurrentClientsLeaveNothingBehind(t *testing.T) {
+  log.SetOutput(ioutil.Discard)
+  defer log.SetOutput(os.Stderr)
+
+  startServices(t)
+
+  var wg sync.WaitGroup
+
+  for i := 0; i < stressClients; i++ {
+    wg.Add(1)
+
+    go func(i int) {
+      defer wg.Done()
+
+      runClient(t, i)
+    }(i)
+  }
+
+  wg.Wait()
+
+  //
+  // Give any synchronizations that were still in flight when the clients disconnected a chance to
+  // be applied and flushed, since that is exactly when stale entries would get re-added.
+  //
+  time.Sleep(500 * time.Millisecond)
+
+  for table, size := range gameserverservice.Instance().TableSizes() {
+    if size != 0 {
+      t.Errorf("Expected the %s table to be empty once every client disconnected. (Size: %d)", table, size)
+    }
+  }
+
+  stopServices(t)
+}
+
+//
+// startServices configures and starts every service that a client needs to be able to connect,
+// authenticate, chat, change areas, and synchronize its player character (by way of the world
+// simulation) over both its primary connection and UDP.
+//
+func startServices(t *testing.T) {
+  t.Helper()
+
+  handlers.PkgInit()
+
+  tokenFile, err := ioutil.TempFile("", "tokens")
+  if err != nil {
+    t.Fatalf("Failed to create the token file. (Error: %s)", err)
+  }
+  defer os.Remove(tokenFile.Name())
+
+  for i := 0; i < stressClients; i++ {
+    fmt.Fprintf(tokenFile, "token%d player%d Player%d\n", i, i, i)
+  }
+
+  tokenFile.Close()
+
+  verifier, err := authservice.CreateStaticTokenVerifier(tokenFile.Name())
+  if err != nil {
+    t.Fatalf("Failed to load the token file. (Error: %s)", err)
+  }
+
+  authservice.Instance().Config(&authservice.Config{Verifier: verifier, MaxFailures: 1})
+
+  playerinfoservice.Instance().Config(&playerinfoservice.Config{
+    Store:     playerinfoservice.CreateMemStore(),
+    CacheSize: stressClients,
+  })
+
+  gameserverservice.Instance().Config(&gameserverservice.Config{
+    TCPAddr:             "127.0.0.1:0",
+    UDPAddr:             "127.0.0.1:0",
+    SyncFlushIntervalMs: 50,
+    Areas: append(gameserverservice.DefaultAreas(), &models.Area{
+      ID:     "Elsewhere",
+      SpawnX: 10,
+      SpawnY: 10,
+    }),
+  })
+
+  worldservice.Instance().Config(&worldservice.Config{TickHz: 20})
+
+  for _, start := range []func() (<-chan bool, error){
+    playerinfoservice.Instance().Start,
+    gameserverservice.Instance().Start,
+    worldservice.Instance().Start,
+  } {
+    chStarted, err := start()
+    if err != nil {
+      t.Fatalf("Failed to start a service. (Error: %s)", err)
+    }
+
+    <-chStarted
+  }
+}
+
+//
+// stopServices stops every service started by startServices().
+//
+func stopServices(t *testing.T) {
+  t.Helper()
+
+  for _, stop := range []func() (<-chan bool, error){
+    worldservice.Instance().Stop,
+    gameserverservice.Instance().Stop,
+    playerinfoservice.Instance().Stop,
+  } {
+    chStopped, err := stop()
+    if err != nil {
+      t.Errorf("Failed to stop a service. (Error: %s)", err)
+
+      continue
+    }
+
+    <-chStopped
+  }
+}
+
+//
+// runClient connects a single in-memory client to the server, has it authenticate, join a chat
+// channel and chat in it, try to form a party with its neighbor, ask for a UDP session, and
+// synchronize its player character over both its primary connection and UDP, and then disconnects
+// it. Some clients change areas along the way. Blocks until the server has completely finished with
+// the client.
+//
+func runClient(t *testing.T, i int) {
+  pipe := transport.CreatePipeConn(1000 + i)
+  chServed := make(chan bool)
+
+  go func() {
+    gameserverservice.Instance().Serve(pipe)
+
+    close(chServed)
+  }()
+
+  //
+  // Keep reading everything that the server sends so that the pipe never fills up, picking out the
+  // client's own player character (the only object of the player type that it is ever told about),
+  // its UDP session, and any news of forming a party along the way.
+  //
+  chObjectID := make(chan string, 1)
+  chUDPBind := make(chan *msgmodels.UDPBind, 1)
+  chInvited := make(chan bool, 1)
+  chPartied := make(chan bool, 1)
+
+  go func() {
+    for raw := range pipe.Read() {
+      var msg rcvMsg
+
+      if err := json.Unmarshal(raw, &msg); err != nil {
+        continue
+      }
+
+      switch msg.Key {
+      case "ObjCreate":
+        data := new(msgmodels.ObjCreate)
+
+        json.Unmarshal(msg.Data, data)
+
+        if data.Type != models.ObjTypePlayer {
+          continue
+        }
+
+        select {
+        case chObjectID <- data.ObjectID:
+        default:
+        }
+      case "UDPBind":
+        data := new(msgmodels.UDPBind)
+
+        json.Unmarshal(msg.Data, data)
+
+        select {
+        case chUDPBind <- data:
+        default:
+        }
+      case "Chat":
+        data := new(msgmodels.Chat)
+
+        json.Unmarshal(msg.Data, data)
+
+        var ch chan bool
+
+        switch {
+        case strings.Contains(data.Content, "has invited you"):
+          ch = chInvited
+        case strings.Contains(data.Content, "has joined the party"):
+          ch = chPartied
+        default:
+          continue
+        }
+
+        select {
+        case ch <- true:
+        default:
+        }
+      }
+    }
+  }()
+
+  objectID := <-chObjectID
+
+  pipe.Write(`{"Key":"Hello","Data":{"ProtocolVersion":1,"Capabilities":["udp"]}}`)
+  pipe.Write(fmt.Sprintf(`{"Key":"Auth","Data":{"Token":"token%d"}}`, i))
+  pipe.Write(`{"Key":"Chat","Data":{"Content":"/join trade"}}`)
+  pipe.Write(`{"Key":"Chat","Data":{"Channel":"trade","Content":"Hello, world!"}}`)
+
+  //
+  // Pair up with a neighbor to form a party, and then wait a while to hear that it formed so that
+  // neither side leaves before the other has had a chance to join. The neighbor may well not have
+  // connected yet (or may have already left), in which case the attempt fails, and that is fine too.
+  //
+  if i%2 == 1 {
+    pipe.Write(fmt.Sprintf(`{"Key":"Chat","Data":{"Content":"/invite Player%d"}}`, i-1))
+  } else {
+    select {
+    case <-chInvited:
+      pipe.Write(fmt.Sprintf(`{"Key":"Chat","Data":{"Content":"/accept Player%d"}}`, i+1))
+    case <-time.After(3 * time.Second):
+    }
+  }
+
+  select {
+  case <-chPartied:
+  case <-time.After(3 * time.Second):
+  }
+
+  pipe.Write(`{"Key":"Chat","Data":{"Channel":"party","Content":"Hello, party!"}}`)
+
+  if i%4 == 0 {
+    pipe.Write(`{"Key":"AreaChange","Data":{"AreaID":"Elsewhere"}}`)
+  }
+
+  for j := 1; j <= stressSyncs; j++ {
+    pipe.Write(fmt.Sprintf(`{"Key":"ObjSync","Data":{"ObjectID":"%s","Variables":{"x":%d}}}`, objectID, j))
+  }
+
+  //
+  // Send the same synchronizations over UDP, racing them against the ones above.
+  //
+  if udpBind := requestUDPBind(pipe, chUDPBind); udpBind == nil {
+    t.Errorf("Client %d was never handed a UDP session.", i)
+  } else if conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", udpBind.Port)); err != nil {
+    t.Errorf("Failed to dial the UDP server. (Error: %s)", err)
+  } else {
+    for j := 1; j <= stressSyncs; j++ {
+      fmt.Fprintf(
+        conn,
+        `{"Key":"UDPObjSync","Data":{"Token":"%s","Seq":%d,"ObjectID":"%s","Variables":{"y":%d}}}`,
+        udpBind.Token, j, objectID, j,
+      )
+    }
+
+    conn.Close()
+  }
+
+  <-pipe.Close()
+  <-chServed
+}
+
+//
+// requestUDPBind asks for a UDP session over the provided pipe and waits for the server to hand one
+// out. The server drops messages to clients that fall behind on reading, and everybody is being
+// told about everybody else connecting (which is slow going under the race detector), so the request
+// is retried every so often before giving up.
+//
+func requestUDPBind(pipe *transport.PipeConn, chUDPBind <-chan *msgmodels.UDPBind) *msgmodels.UDPBind {
+  for attempt := 0; attempt < 30; attempt++ {
+    pipe.Write(`{"Key":"UDPBind","Data":{}}`)
+
+    select {
+    case udpBind := <-chUDPBind:
+      return udpBind
+    case <-time.After(time.Second):
+    }
+  }
+
+  return nil
+}
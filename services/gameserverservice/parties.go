@@ -0,0 +1,190 @@
+package gameserverservice
+
+import (
+  "errors"
+  "log"
+
+  "github.com/lukehollenback/arcane-server/models"
+)
+
+const (
+  //
+  // MaxPartySize is the largest number of clients that may be in a single party.
+  //
+  MaxPartySize = 8
+)
+
+var (
+  //
+  // ErrAlreadyInParty is returned when a client that is already in a party is invited to (or attempts
+  // to join) another one.
+  //
+  ErrAlreadyInParty = errors.New("already in a party")
+
+  //
+  // ErrNotInParty is returned when a client that is not in a party attempts to leave one.
+  //
+  ErrNotInParty = errors.New("not in a party")
+
+  //
+  // ErrNotInvitedToParty is returned when a client attempts to join a party that it has not been
+  // invited to.
+  //
+  ErrNotInvitedToParty = errors.New("no invitation to the party has been received")
+
+  //
+  // ErrPartyFull is returned when inviting somebody to (or joining) a party that already has
+  // MaxPartySize members.
+  //
+  ErrPartyFull = errors.New("the party is full")
+
+  //
+  // ErrPartyInviteSelf is returned when a client attempts to invite itself to a party.
+  //
+  ErrPartyInviteSelf = errors.New("can not invite yourself")
+)
+
+//
+// party represents a group of clients that share the party chat channel.
+//
+type party struct {
+  members map[int]*models.Client // Members of the party keyed by connection identifier.
+  invited map[int]bool           // Connection identifiers of the clients that have been invited to the party but have not yet joined it.
+}
+
+//
+// InviteToParty invites the provided target client to the provided client's party so that it may
+// then join it with JoinParty(). If the inviting client is not in a party yet, a new one is formed
+// around it.
+//
+func (o *GameServerService) InviteToParty(client *models.Client, target *models.Client) error {
+  if client.ID() == target.ID() {
+    return ErrPartyInviteSelf
+  }
+
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  if _, prs := o.parties[target.ID()]; prs {
+    return ErrAlreadyInParty
+  }
+
+  p, prs := o.parties[client.ID()]
+  if !prs {
+    p = &party{
+      members: map[int]*models.Client{client.ID(): client},
+      invited: make(map[int]bool),
+    }
+
+    o.parties[client.ID()] = p
+  }
+
+  if len(p.members) >= MaxPartySize {
+    return ErrPartyFull
+  }
+
+  p.invited[target.ID()] = true
+
+  log.Printf("%sInvited client %d to its party.", client.LogPrefix(), target.ID())
+
+  return nil
+}
+
+//
+// JoinParty makes the provided client a member of the party of the provided inviting client, as
+// long as it has been invited to it.
+//
+func (o *GameServerService) JoinParty(client *models.Client, inviter *models.Client) error {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  if _, prs := o.parties[client.ID()]; prs {
+    return ErrAlreadyInParty
+  }
+
+  p, prs := o.parties[inviter.ID()]
+  if !prs || !p.invited[client.ID()] {
+    return ErrNotInvitedToParty
+  }
+
+  if len(p.members) >= MaxPartySize {
+    return ErrPartyFull
+  }
+
+  delete(p.invited, client.ID())
+
+  p.members[client.ID()] = client
+  o.parties[client.ID()] = p
+
+  log.Printf("%sJoined the party of client %d.", client.LogPrefix(), inviter.ID())
+
+  return nil
+}
+
+//
+// LeaveParty removes the provided client from its party. A party that is left with a single member
+// is disbanded.
+//
+func (o *GameServerService) LeaveParty(client *models.Client) error {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+
+  if _, prs := o.parties[client.ID()]; !prs {
+    return ErrNotInParty
+  }
+
+  o.forgetClientInPartiesLocked(client)
+
+  log.Printf("%sLeft its party.", client.LogPrefix())
+
+  return nil
+}
+
+//
+// PartyMembers returns the members of the provided client's party (including the client itself),
+// or nil if it is not in one.
+//
+func (o *GameServerService) PartyMembers(client *models.Client) []*models.Client {
+  o.mu.RLock()
+  defer o.mu.RUnlock()
+
+  p, prs := o.parties[client.ID()]
+  if !prs {
+    return nil
+  }
+
+  clients := make([]*models.Client, 0, len(p.members))
+
+  for _, member := range p.members {
+    clients = append(clients, member)
+  }
+
+  return clients
+}
+
+//
+// forgetClientInPartiesLocked removes the provided client from its party (disbanding the party if
+// that leaves it with a single member) and withdraws every invitation that it has received. It is
+// up to the caller to hold the service's lock.
+//
+func (o *GameServerService) forgetClientInPartiesLocked(client *models.Client) {
+  for _, p := range o.parties {
+    delete(p.invited, client.ID())
+  }
+
+  p, prs := o.parties[client.ID()]
+  if !prs {
+    return
+  }
+
+  delete(p.members, client.ID())
+  delete(o.parties, client.ID())
+
+  if len(p.members) > 1 {
+    return
+  }
+
+  for id := range p.members {
+    delete(o.parties, id)
+  }
+}
@@ -0,0 +1,242 @@
+package gameserverservice
+
+import (
+  "log"
+  "reflect"
+  "time"
+
+  "github.com/lukehollenback/arcane-server/models"
+  "github.com/lukehollenback/arcane-server/models/msgmodels"
+  "github.com/lukehollenback/arcane-server/util"
+)
+
+//
+// syncStatsIntervalSecs is how often the object synchronization byte counters are logged.
+//
+const syncStatsIntervalSecs = 60
+
+//
+// pendingSync represents an object synchronization that is waiting for the next flush.
+//
+type pendingSync struct {
+  objSync           *msgmodels.ObjSync // The synchronization to send.
+  excludedClientIDs []int              // Connection identifiers of clients that should not be told about it.
+}
+
+//
+// QueueObjSync queues up the provided object synchronization to be sent to all clients in its area
+// (except for those specified to be excluded) during the next flush. Each recipient will only be
+// sent the variables that have changed since it was last told about the object, and all of the
+// synchronizations for an area will be bundled into a single "ObjSyncBatch"-type message.
+//
+func (o *GameServerService) QueueObjSync(objSync *msgmodels.ObjSync, excludedClientIDs []int) {
+  o.syncMu.Lock()
+  defer o.syncMu.Unlock()
+
+  o.pendingSyncs[objSync.AreaID] = append(o.pendingSyncs[objSync.AreaID], &pendingSync{
+    objSync:           objSync,
+    excludedClientIDs: excludedClientIDs,
+  })
+}
+
+//
+// SyncStats returns the number of bytes that object synchronization would have sent had every
+// synchronization been sent individually and in full, along with the number of bytes actually sent.
+//
+func (o *GameServerService) SyncStats() (int64, int64) {
+  o.syncMu.Lock()
+  defer o.syncMu.Unlock()
+
+  return o.syncBytesFull, o.syncBytesSent
+}
+
+//
+// flushObjSyncs loops at the configured flush interval and sends out any queued object
+// synchronizations, until it is told to stop. Intended to be run in its own goroutine.
+//
+func (o *GameServerService) flushObjSyncs() {
+  log.Printf("Object synchronization flushing has started.")
+
+  flushTicker := time.NewTicker(time.Duration(o.config.SyncFlushIntervalMs) * time.Millisecond)
+  defer flushTicker.Stop()
+
+  statsTicker := time.NewTicker(syncStatsIntervalSecs * time.Second)
+  defer statsTicker.Stop()
+
+  for cont := true; cont; {
+    select {
+    case <-o.chSyncKill:
+      cont = false
+    case <-flushTicker.C:
+      o.flushPendingSyncs()
+    case <-statsTicker.C:
+      full, sent := o.SyncStats()
+
+      if full > 0 {
+        log.Printf(
+          "Object synchronization has sent %d bytes instead of %d. (Saved: %d bytes, %.1f%%)",
+          sent, full, full-sent, 100*float64(full-sent)/float64(full),
+        )
+      }
+    }
+  }
+
+  log.Printf("Object synchronization flushing has stopped.")
+
+  o.chSyncStopped <- true
+}
+
+//
+// flushPendingSyncs sends out all queued object synchronizations.
+//
+func (o *GameServerService) flushPendingSyncs() {
+  //
+  // Grab everything that is queued up so that new synchronizations can keep being queued while we
+  // work.
+  //
+  o.syncMu.Lock()
+
+  pending := o.pendingSyncs
+  o.pendingSyncs = make(map[string][]*pendingSync)
+
+  o.syncMu.Unlock()
+
+  //
+  // Work out, and then send, the batch destined for each client in each area.
+  //
+  for areaID, syncs := range pending {
+    for _, client := range o.snapshotAreaClients(areaID) {
+      batch, fullBytes := o.buildObjSyncBatch(client, areaID, syncs)
+      if batch == nil {
+        continue
+      }
+
+      batchMsg := msgmodels.CreateMsg(batch)
+      cache := make(encodedMsgCache)
+
+      o.syncMu.Lock()
+      o.syncBytesFull += fullBytes
+      o.syncBytesSent += int64(len(cache.encode(batchMsg, client.Codec())) + 1)
+      o.syncMu.Unlock()
+
+      o.sendEncodedMessage(client, batchMsg, cache)
+    }
+  }
+}
+
+//
+// buildObjSyncBatch works out which of the provided synchronizations the provided client needs to
+// be told about, stripped down to only the variables that it does not already know the values of.
+// It also returns how many bytes sending each synchronization individually and in full would have
+// taken. If there is nothing to tell the client, a nil batch is returned.
+//
+func (o *GameServerService) buildObjSyncBatch(
+  client *models.Client,
+  areaID string,
+  syncs []*pendingSync,
+) (*msgmodels.ObjSyncBatch, int64) {
+  o.syncMu.Lock()
+  defer o.syncMu.Unlock()
+
+  clientID := client.ID()
+  codec := client.Codec()
+
+  //
+  // Skip clients that have disconnected since their area was snapshotted. Their entry in the known
+  // variable table has already been (or is about to be) forgotten, and recreating it would leak it.
+  //
+  // NOTE: This must be checked while holding the synchronization lock, since that is what orders it
+  //  against the client's entry being forgotten once it has been removed from the client table.
+  //
+  if o.client(clientID) != client {
+    return nil, 0
+  }
+
+  known, prs := o.knownVars[clientID]
+  if !prs {
+    known = make(map[string]map[string]interface{})
+    o.knownVars[clientID] = known
+  }
+
+  var fullBytes int64
+
+  deltas := make(map[string]*msgmodels.ObjSync)
+  order := make([]string, 0)
+
+  for _, p := range syncs {
+    if p.excludedClientIDs != nil && util.SliceContainsInt(clientID, p.excludedClientIDs) {
+      continue
+    }
+
+    if rawMsg, err := codec.Encode(msgmodels.CreateMsg(p.objSync)); err == nil {
+      fullBytes += int64(len(rawMsg) + 1)
+    }
+
+    knownObjVars, prs := known[p.objSync.ObjectID]
+    if !prs {
+      knownObjVars = make(map[string]interface{})
+      known[p.objSync.ObjectID] = knownObjVars
+    }
+
+    for key, val := range p.objSync.Variables {
+      if knownVal, prs := knownObjVars[key]; prs && reflect.DeepEqual(knownVal, val) {
+        continue
+      }
+
+      knownObjVars[key] = val
+
+      delta, prs := deltas[p.objSync.ObjectID]
+      if !prs {
+        delta = &msgmodels.ObjSync{
+          ObjectID:  p.objSync.ObjectID,
+          AreaID:    areaID,
+          Variables: make(map[string]interface{}),
+        }
+
+        deltas[p.objSync.ObjectID] = delta
+        order = append(order, p.objSync.ObjectID)
+      }
+
+      delta.Variables[key] = val
+    }
+  }
+
+  if len(order) == 0 {
+    return nil, fullBytes
+  }
+
+  batch := &msgmodels.ObjSyncBatch{
+    AreaID: areaID,
+    Syncs:  make([]*msgmodels.ObjSync, 0, len(order)),
+  }
+
+  for _, objectID := range order {
+    batch.Syncs = append(batch.Syncs, deltas[objectID])
+  }
+
+  return batch, fullBytes
+}
+
+//
+// forgetKnownVarsOfClient discards everything that the provided client is known to have been told
+// about synchronized objects (e.g. because it disconnected or changed areas).
+//
+func (o *GameServerService) forgetKnownVarsOfClient(client *models.Client) {
+  o.syncMu.Lock()
+  defer o.syncMu.Unlock()
+
+  delete(o.knownVars, client.ID())
+}
+
+//
+// forgetKnownVarsOfObject discards everything that all clients are known to have been told about
+// the object with the specified unique identifier (e.g. because it was destroyed or changed areas).
+//
+func (o *GameServerService) forgetKnownVarsOfObject(objectID string) {
+  o.syncMu.Lock()
+  defer o.syncMu.Unlock()
+
+  for _, known := range o.knownVars {
+    delete(known, objectID)
+  }
+}
@@ -0,0 +1,304 @@
+package gameserverservice
+
+import (
+  "crypto/rand"
+  "encoding/hex"
+  "errors"
+  "log"
+  "net"
+  "reflect"
+
+  "github.com/lukehollenback/arcane-server/models"
+  "github.com/lukehollenback/arcane-server/models/msgmodels"
+  "github.com/mitchellh/mapstructure"
+)
+
+const (
+  //
+  // udpMaxDatagramBytes is the largest datagram that will be read from the UDP socket. Anything
+  // larger is truncated (and will therefore fail to deserialize).
+  //
+  udpMaxDatagramBytes = 8 * 1024
+
+  //
+  // udpTokenBytes is the number of random bytes that make up a UDP session token.
+  //
+  udpTokenBytes = 16
+
+  //
+  // udpQueueLen is the number of object synchronizations received over UDP that may be waiting to be
+  // handled for a single client. Any more than that are dropped, just as if the network had dropped
+  // them.
+  //
+  udpQueueLen = 16
+)
+
+var (
+  //
+  // ErrUDPDisabled is returned when a client asks for a UDP session but the server is not listening
+  // for UDP datagrams.
+  //
+  ErrUDPDisabled = errors.New("the server is not accepting UDP datagrams")
+
+  //
+  // udpObjSyncKey is the message type key that UDP datagrams must carry.
+  //
+  udpObjSyncKey = reflect.TypeOf(new(msgmodels.UDPObjSync)).Elem().Name()
+)
+
+//
+// udpBinding ties a UDP session token to the client that it was handed out to.
+//
+type udpBinding struct {
+  client  *models.Client      // The client that the session token was handed out to.
+  addr    string              // The remote endpoint that the client's datagrams come from. Empty until the first one arrives.
+  lastSeq int64               // The sequence number of the most recently accepted datagram.
+  queue   chan *msgmodels.Msg // Accepted datagrams waiting to be handled. See dispatchDatagrams().
+}
+
+//
+// startUDPServer starts listening for UDP datagrams on the configured address. The socket is bound
+// before returning so that configuration problems are reported right away.
+//
+func (o *GameServerService) startUDPServer() error {
+  addr, err := net.ResolveUDPAddr("udp", o.config.UDPAddr)
+  if err != nil {
+    return err
+  }
+
+  conn, err := net.ListenUDP("udp", addr)
+  if err != nil {
+    return err
+  }
+
+  o.udpMu.Lock()
+
+  o.udpConn = conn
+  o.udpBindings = make(map[string]*udpBinding)
+  o.udpTokens = make(map[int]string)
+
+  o.udpMu.Unlock()
+
+  o.chUDPStopped = make(chan bool)
+
+  go o.listenUDP(conn)
+
+  log.Printf("Listening for UDP datagrams at %s.", conn.LocalAddr())
+
+  return nil
+}
+
+//
+// stopUDPServer closes the UDP socket and blocks until the goroutine reading from it has stopped.
+//
+func (o *GameServerService) stopUDPServer() {
+  o.udpMu.Lock()
+
+  conn := o.udpConn
+  o.udpConn = nil
+
+  for _, binding := range o.udpBindings {
+    close(binding.queue)
+  }
+
+  o.udpBindings = make(map[string]*udpBinding)
+  o.udpTokens = make(map[int]string)
+
+  o.udpMu.Unlock()
+
+  conn.Close()
+
+  <-o.chUDPStopped
+
+  log.Printf("The UDP server has stopped.")
+}
+
+//
+// listenUDP reads datagrams from the provided UDP socket until it is closed. Intended to be run in
+// its own goroutine.
+//
+func (o *GameServerService) listenUDP(conn *net.UDPConn) {
+  buf := make([]byte, udpMaxDatagramBytes)
+
+  for {
+    n, addr, err := conn.ReadFromUDP(buf)
+    if err != nil {
+      break
+    }
+
+    o.handleDatagram(addr.String(), buf[:n])
+  }
+
+  o.chUDPStopped <- true
+}
+
+//
+// handleDatagram processes a single datagram received over the UDP socket. Datagrams are silently
+// dropped unless they carry a known session token, come from the endpoint that the session is bound
+// to, and have a newer sequence number than any previously accepted datagram for the session.
+//
+// NOTE: Only object synchronization is accepted over UDP. Everything else (e.g. chat and
+//  authentication) must go over the client's primary connection, where delivery is reliable.
+//
+func (o *GameServerService) handleDatagram(addr string, datagram []byte) {
+  //
+  // Deserialize the datagram. Clients may use whichever wire format they like, and JSON is easy to
+  // tell apart from the binary codec by its opening brace.
+  //
+  var codec msgmodels.Codec = msgmodels.BinaryCodec{}
+
+  if len(datagram) > 0 && datagram[0] == '{' {
+    codec = msgmodels.JSONCodec{}
+  }
+
+  m, err := codec.Decode(datagram)
+  if err != nil || m.Key != udpObjSyncKey {
+    return
+  }
+
+  data := new(msgmodels.UDPObjSync)
+
+  if err := mapstructure.Decode(m.Data, data); err != nil {
+    return
+  }
+
+  //
+  // Figure out which client the datagram belongs to, make sure that it is not stale, and queue it up
+  // to be handled by the same handler that processes the object synchronizations received over the
+  // client's primary connection so that it is validated in exactly the same way (and never at the
+  // same time as one of the client's other messages).
+  //
+  objSyncMsg := msgmodels.CreateMsg(&msgmodels.ObjSync{
+    ObjectID:  data.ObjectID,
+    AreaID:    data.AreaID,
+    Variables: data.Variables,
+  })
+
+  client := o.acceptDatagram(data.Token, addr, data.Seq, objSyncMsg)
+  if client == nil {
+    return
+  }
+
+  client.UpdateLastMsgTimestamp()
+
+  log.Printf("%s(UDP) %+v", client.RcvLogPrefix(), *m)
+}
+
+//
+// dispatchDatagrams hands each of the object synchronizations queued up for a UDP session off to the
+// client's message handlers, one at a time, until the session's queue is closed. Intended to be run
+// in its own goroutine so that a client that is slow to handle its messages only ever holds up its
+// own datagrams rather than the whole UDP socket.
+//
+func (o *GameServerService) dispatchDatagrams(client *models.Client, queue <-chan *msgmodels.Msg) {
+  for msg := range queue {
+    o.executeMsgHandler(client, msg)
+  }
+}
+
+//
+// acceptDatagram checks a datagram's session token, source endpoint, and sequence number against
+// the session that the token belongs to, and then queues up the provided message to be handled on
+// behalf of the session's client. Returns the client that the datagram belongs to, or nil if the
+// datagram was dropped (including because the client already has a full queue).
+//
+func (o *GameServerService) acceptDatagram(
+  token string,
+  addr string,
+  seq int64,
+  msg *msgmodels.Msg,
+) *models.Client {
+  o.udpMu.Lock()
+  defer o.udpMu.Unlock()
+
+  binding, prs := o.udpBindings[token]
+  if !prs {
+    return nil
+  }
+
+  //
+  // The first datagram received for a session binds it to the endpoint that it came from. Anything
+  // coming from elsewhere afterwards is assumed to be spoofed.
+  //
+  if len(binding.addr) == 0 {
+    binding.addr = addr
+  } else if binding.addr != addr {
+    return nil
+  }
+
+  if seq <= binding.lastSeq {
+    return nil
+  }
+
+  binding.lastSeq = seq
+
+  select {
+  case binding.queue <- msg:
+    return binding.client
+  default:
+    return nil
+  }
+}
+
+//
+// BindUDP hands out a new UDP session token to the specified client, replacing any that it was
+// handed previously. Returns the token along with the port that datagrams should be sent to.
+//
+func (o *GameServerService) BindUDP(client *models.Client) (string, int, error) {
+  raw := make([]byte, udpTokenBytes)
+
+  if _, err := rand.Read(raw); err != nil {
+    return "", 0, err
+  }
+
+  token := hex.EncodeToString(raw)
+
+  o.udpMu.Lock()
+  defer o.udpMu.Unlock()
+
+  if o.udpConn == nil {
+    return "", 0, ErrUDPDisabled
+  }
+
+  if prevToken, prs := o.udpTokens[client.ID()]; prs {
+    close(o.udpBindings[prevToken].queue)
+    delete(o.udpBindings, prevToken)
+  }
+
+  binding := &udpBinding{
+    client: client,
+    queue:  make(chan *msgmodels.Msg, udpQueueLen),
+  }
+
+  o.udpBindings[token] = binding
+  o.udpTokens[client.ID()] = token
+
+  go o.dispatchDatagrams(client, binding.queue)
+
+  return token, o.udpConn.LocalAddr().(*net.UDPAddr).Port, nil
+}
+
+//
+// forgetUDPBinding invalidates the UDP session token that was handed out to the specified client
+// (if any).
+//
+func (o *GameServerService) forgetUDPBinding(client *models.Client) {
+  o.udpMu.Lock()
+  defer o.udpMu.Unlock()
+
+  if token, prs := o.udpTokens[client.ID()]; prs {
+    close(o.udpBindings[token].queue)
+    delete(o.udpBindings, token)
+    delete(o.udpTokens, client.ID())
+  }
+}
+
+//
+// UDPEnabled returns whether or not the server is currently accepting UDP datagrams.
+//
+func (o *GameServerService) UDPEnabled() bool {
+  o.udpMu.Lock()
+  defer o.udpMu.Unlock()
+
+  return o.udpConn != nil
+}
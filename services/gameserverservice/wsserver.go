@@ -0,0 +1,205 @@
+package gameserverservice
+
+import (
+  "context"
+  "log"
+  "net"
+  "net/http"
+  "net/url"
+  "strings"
+  "time"
+
+  "github.com/gorilla/websocket"
+  "github.com/lukehollenback/arcane-server/transport"
+)
+
+const (
+  //
+  // wsFirstID is the connection identifier assigned to the first WebSocket connection. It is offset
+  // far beyond anything the TCP/IP packet server will ever hand out so that clients from both
+  // transports can share the same tables.
+  //
+  wsFirstID = 1 << 30
+
+  //
+  // wsShutdownTimeout is how long the WebSocket server is given to stop accepting connections
+  // during shut-down.
+  //
+  wsShutdownTimeout = 5 * time.Second
+
+  //
+  // WSAnyOrigin may be listed as an allowed WebSocket origin to accept connections from pages hosted
+  // anywhere at all.
+  //
+  WSAnyOrigin = "*"
+)
+
+//
+// startWSServer starts listening for WebSocket connections on the configured address and path. The
+// listening socket is bound before returning so that configuration problems are reported right
+// away.
+//
+func (o *GameServerService) startWSServer() error {
+  listener, err := net.Listen("tcp", o.config.WSAddr)
+  if err != nil {
+    return err
+  }
+
+  path := o.config.WSPath
+  if len(path) == 0 {
+    path = "/"
+  }
+
+  o.wsUpgrader = &websocket.Upgrader{
+    ReadBufferSize:  4096,
+    WriteBufferSize: 4096,
+    CheckOrigin:     o.checkWSOrigin,
+  }
+
+  mux := http.NewServeMux()
+  mux.HandleFunc(path, o.handleWSUpgrade)
+
+  o.wsMu.Lock()
+
+  o.wsConns = make(map[int]*transport.WSConn)
+  o.wsNextID = wsFirstID
+
+  o.wsMu.Unlock()
+
+  o.wsServer = &http.Server{
+    Handler: mux,
+  }
+
+  go func(server *http.Server) {
+    err := server.Serve(listener)
+    if err != nil && err != http.ErrServerClosed {
+      log.Printf("The WebSocket server has stopped unexpectedly. (Error: %s)", err)
+    }
+  }(o.wsServer)
+
+  log.Printf("Listening for WebSocket connections at ws://%s%s.", listener.Addr(), path)
+
+  return nil
+}
+
+//
+// stopWSServer stops accepting WebSocket connections, closes all of the ones that are still open,
+// and blocks until they have all been cleaned up.
+//
+func (o *GameServerService) stopWSServer() {
+  //
+  // Stop accepting new connections. Connections that have already been upgraded are no longer
+  // tracked by the HTTP server, so they must be closed by hand afterwards.
+  //
+  ctx, cancel := context.WithTimeout(context.Background(), wsShutdownTimeout)
+  defer cancel()
+
+  err := o.wsServer.Shutdown(ctx)
+  if err != nil {
+    log.Printf("Failed to gracefully stop the WebSocket server. (Error: %s)", err)
+  }
+
+  o.wsMu.Lock()
+
+  conns := make([]*transport.WSConn, 0, len(o.wsConns))
+
+  for _, conn := range o.wsConns {
+    conns = append(conns, conn)
+  }
+
+  o.wsMu.Unlock()
+
+  for _, conn := range conns {
+    conn.Close()
+  }
+
+  o.wsWG.Wait()
+
+  o.wsServer = nil
+
+  log.Printf("The WebSocket server has stopped.")
+}
+
+//
+// handleWSUpgrade upgrades an incoming HTTP request to a WebSocket connection and then reads
+// messages from it until it closes. A separate instance runs in its own goroutine for each
+// connection.
+//
+func (o *GameServerService) handleWSUpgrade(w http.ResponseWriter, r *http.Request) {
+  ws, err := o.wsUpgrader.Upgrade(w, r, nil)
+  if err != nil {
+    // NOTE: The upgrader has already replied to the request with an appropriate HTTP error.
+
+    log.Printf("Failed to upgrade a request from %s to a WebSocket connection. (Error: %s)", r.RemoteAddr, err)
+
+    return
+  }
+
+  conn := o.addWSConn(ws)
+
+  defer o.forgetWSConn(conn)
+
+  o.Serve(conn)
+}
+
+//
+// checkWSOrigin checks whether or not the provided request to upgrade to a WebSocket connection
+// comes from a page that is allowed to connect. Pages served from the same host as the WebSocket
+// server itself and from the configured allowed origins are, as are clients that are not browsers
+// (and therefore do not send an origin at all).
+//
+// NOTE: Browsers send along cookies with WebSocket requests from any page, so only trusted origins
+//  should be allowed. Clients authenticate with explicit tokens, so allowing any origin (with
+//  WSAnyOrigin) is safe as long as nothing else is served from the same host.
+//
+func (o *GameServerService) checkWSOrigin(r *http.Request) bool {
+  origin := r.Header.Get("Origin")
+  if len(origin) == 0 {
+    return true
+  }
+
+  for _, allowed := range o.config.WSAllowedOrigins {
+    if allowed == WSAnyOrigin || strings.EqualFold(allowed, origin) {
+      return true
+    }
+  }
+
+  u, err := url.Parse(origin)
+  if err != nil || !strings.EqualFold(u.Host, r.Host) {
+    log.Printf("Refused a WebSocket connection from %s. (Origin: %s)", r.RemoteAddr, origin)
+
+    return false
+  }
+
+  return true
+}
+
+//
+// addWSConn wraps the provided WebSocket connection, assigns it a unique connection identifier, and
+// adds it to the WebSocket connection table.
+//
+func (o *GameServerService) addWSConn(ws *websocket.Conn) *transport.WSConn {
+  o.wsMu.Lock()
+  defer o.wsMu.Unlock()
+
+  conn := transport.CreateWSConn(o.wsNextID, ws)
+
+  o.wsNextID++
+  o.wsConns[conn.ID()] = conn
+  o.wsWG.Add(1)
+
+  return conn
+}
+
+//
+// forgetWSConn removes the provided WebSocket connection from the WebSocket connection table.
+//
+func (o *GameServerService) forgetWSConn(conn *transport.WSConn) {
+  o.wsMu.Lock()
+
+  delete(o.wsConns, conn.ID())
+
+  o.wsMu.Unlock()
+
+  o.wsWG.Done()
+}
@@ -0,0 +1,49 @@
+package gameserverservice_test
+
+import (
+  "io/ioutil"
+  "log"
+  "net/http/httptest"
+  "os"
+  "testing"
+
+  "github.com/lukehollenback/arcane-server/services/gameserverservice"
+)
+
+func TestCheckWSOrigin(t *testing.T) {
+  log.SetOutput(ioutil.Discard)
+  defer log.SetOutput(os.Stderr)
+
+  tests := []struct {
+    allowed  []string
+    origin   string
+    expected bool
+  }{
+    {nil, "", true},
+    {nil, "http://game.example.com", true},
+    {nil, "https://GAME.example.com", true},
+    {nil, "https://evil.example.com", false},
+    {nil, "null", false},
+    {[]string{"https://play.example.com"}, "https://play.example.com", true},
+    {[]string{"https://play.example.com"}, "http://play.example.com", false},
+    {[]string{"https://play.example.com"}, "https://evil.example.com", false},
+    {[]string{gameserverservice.WSAnyOrigin}, "https://evil.example.com", true},
+  }
+
+  for _, test := range tests {
+    gameserverservice.Instance().Config(&gameserverservice.Config{WSAllowedOrigins: test.allowed})
+
+    r := httptest.NewRequest("GET", "http://game.example.com/ws", nil)
+
+    if len(test.origin) > 0 {
+      r.Header.Set("Origin", test.origin)
+    }
+
+    if actual := gameserverservice.Instance().CheckWSOrigin(r); actual != test.expected {
+      t.Errorf(
+        "Unexpected origin check. (Allowed: %q) (Origin: %q) (Expected: %t) (Actual: %t)",
+        test.allowed, test.origin, test.expected, actual,
+      )
+    }
+  }
+}
@@ -0,0 +1,324 @@
+package msghandlerservice
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+)
+
+const (
+	//
+	// rateSweepInterval is how often the rate limiter forgets about buckets that have refilled
+	// completely (e.g. because their client has gone quiet or disconnected).
+	//
+	rateSweepInterval = time.Minute
+)
+
+//
+// Auth is a middleware that refuses to pass along messages whose registered handler requires
+// authentication unless the client has authenticated. It is always part of the chain. See Use().
+//
+func Auth(next Handler) Handler {
+	return func(client *models.Client, msg *msgmodels.Msg) error {
+		if Instance().RequiresAuth(msg.Key) && !client.Authed() {
+			return &Error{
+				Code: msgmodels.ErrCodeAuthRequired,
+				Err:  errors.New("message handling requires authentication"),
+			}
+		}
+
+		return next(client, msg)
+	}
+}
+
+//
+// Recover is a middleware that recovers from any panic that occurs further down the chain, logs it
+// (along with the stack trace), counts it, and turns it into an error so that one bad message can
+// not take down the whole server. It is always part of the chain, right after every middleware
+// added with Use(), so that the resulting error is seen by all of them (e.g. Logging). See Use().
+//
+func Recover(next Handler) Handler {
+	return func(client *models.Client, msg *msgmodels.Msg) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = Instance().recordPanic(client, msg, r)
+			}
+		}()
+
+		return next(client, msg)
+	}
+}
+
+//
+// Logging is a middleware that logs the outcome of handling each message as a line of key=value
+// pairs that can easily be searched and parsed.
+//
+func Logging(next Handler) Handler {
+	return func(client *models.Client, msg *msgmodels.Msg) error {
+		start := time.Now()
+		err := next(client, msg)
+		duration := time.Since(start)
+
+		if err != nil {
+			log.Printf(
+				"%sevent=handled key=%q id=%q player=%q duration=%s result=error code=%s error=%q",
+				client.LogPrefix(), msg.Key, msg.ID, client.PlayerID(), duration, ErrorCode(err), err,
+			)
+		} else {
+			log.Printf(
+				"%sevent=handled key=%q id=%q player=%q duration=%s result=ok",
+				client.LogPrefix(), msg.Key, msg.ID, client.PlayerID(), duration,
+			)
+		}
+
+		return err
+	}
+}
+
+//
+// KeyMetrics represents the timing metrics that have been gathered for one message type key.
+//
+type KeyMetrics struct {
+	Count  int64         // Number of messages that have been handled.
+	Errors int64         // Number of messages whose handling resulted in an error.
+	Total  time.Duration // Total time spent handling messages.
+	Max    time.Duration // Longest time spent handling a single message.
+}
+
+//
+// Metrics gathers timing metrics for each message type key by way of its middleware.
+//
+type Metrics struct {
+	mu   *sync.Mutex            // Mutex to protect against concurrent access to the metrics table.
+	keys map[string]*KeyMetrics // Table of gathered metrics keyed by message type key.
+}
+
+//
+// CreateMetrics constructs a new, empty, metrics gatherer and returns a pointer to it.
+//
+func CreateMetrics() *Metrics {
+	return &Metrics{
+		mu:   &sync.Mutex{},
+		keys: make(map[string]*KeyMetrics),
+	}
+}
+
+//
+// Middleware is a middleware that times each message that passes through it.
+//
+func (o *Metrics) Middleware(next Handler) Handler {
+	return func(client *models.Client, msg *msgmodels.Msg) error {
+		start := time.Now()
+		err := next(client, msg)
+		duration := time.Since(start)
+
+		o.mu.Lock()
+		defer o.mu.Unlock()
+
+		metrics, prs := o.keys[msg.Key]
+		if !prs {
+			metrics = &KeyMetrics{}
+			o.keys[msg.Key] = metrics
+		}
+
+		metrics.Count++
+		metrics.Total += duration
+
+		if duration > metrics.Max {
+			metrics.Max = duration
+		}
+
+		if err != nil {
+			metrics.Errors++
+		}
+
+		return err
+	}
+}
+
+//
+// Snapshot returns a copy of the metrics gathered so far keyed by message type key.
+//
+func (o *Metrics) Snapshot() map[string]KeyMetrics {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	snapshot := make(map[string]KeyMetrics, len(o.keys))
+
+	for key, metrics := range o.keys {
+		snapshot[key] = *metrics
+	}
+
+	return snapshot
+}
+
+//
+// LogSummary logs one line of the metrics gathered so far for each message type key.
+//
+func (o *Metrics) LogSummary() {
+	snapshot := o.Snapshot()
+	keys := make([]string, 0, len(snapshot))
+
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		metrics := snapshot[key]
+
+		log.Printf(
+			"event=metrics key=%q count=%d errors=%d avg=%s max=%s",
+			key,
+			metrics.Count,
+			metrics.Errors,
+			metrics.Total/time.Duration(metrics.Count),
+			metrics.Max,
+		)
+	}
+}
+
+//
+// RateLimit represents how quickly a client may send messages of a given type.
+//
+type RateLimit struct {
+	PerSec float64 // Sustained number of messages allowed per second. Zero (or less) means unlimited.
+	Burst  int     // Number of messages that may be sent in a quick burst before the sustained rate kicks in.
+}
+
+//
+// RateLimiter limits how quickly each client may send each type of message by way of its
+// middleware. It uses a token bucket per client and message type key.
+//
+type RateLimiter struct {
+	mu        *sync.Mutex                   // Mutex to protect against concurrent access to the bucket table.
+	def       RateLimit                     // The rate limit for message type keys without one of their own.
+	keyLimits map[string]RateLimit          // Rate limits for specific message type keys.
+	buckets   map[rateBucketKey]*rateBucket // Table of token buckets keyed by client and message type key.
+	lastSweep time.Time                     // When buckets that have refilled completely were last forgotten.
+}
+
+//
+// rateBucketKey identifies the token bucket of one client for one message type key.
+//
+type rateBucketKey struct {
+	clientID int    // The connection identifier of the client.
+	key      string // The message type key.
+}
+
+//
+// rateBucket represents a token bucket.
+//
+type rateBucket struct {
+	tokens float64   // Number of messages that may currently be sent.
+	last   time.Time // When the bucket was last refilled.
+}
+
+//
+// CreateRateLimiter constructs a new rate limiter that applies the provided default rate limit to
+// every message type key except those given their own rate limit, and returns a pointer to it.
+//
+func CreateRateLimiter(def RateLimit, keyLimits map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		mu:        &sync.Mutex{},
+		def:       def,
+		keyLimits: keyLimits,
+		buckets:   make(map[rateBucketKey]*rateBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+//
+// Middleware is a middleware that refuses to pass along messages from clients that have exceeded
+// the rate limit for the message's type.
+//
+func (o *RateLimiter) Middleware(next Handler) Handler {
+	return func(client *models.Client, msg *msgmodels.Msg) error {
+		if !o.allow(client.ID(), msg.Key, time.Now()) {
+			return &Error{
+				Code: msgmodels.ErrCodeRateLimited,
+				Err:  fmt.Errorf("too many \"%s\" messages have been sent too quickly", msg.Key),
+			}
+		}
+
+		return next(client, msg)
+	}
+}
+
+//
+// allow takes a token from the specified client's bucket for the specified message type key.
+// Returns false if there was no token to take.
+//
+func (o *RateLimiter) allow(clientID int, key string, now time.Time) bool {
+	limit, prs := o.keyLimits[key]
+	if !prs {
+		limit = o.def
+	}
+
+	if limit.PerSec <= 0 {
+		return true
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.sweep(now)
+
+	//
+	// Refill the bucket according to how long it has been since it was last touched, and then try
+	// to take a token out of it.
+	//
+	bucketKey := rateBucketKey{clientID: clientID, key: key}
+
+	bucket, prs := o.buckets[bucketKey]
+	if !prs {
+		bucket = &rateBucket{tokens: float64(limit.Burst), last: now}
+		o.buckets[bucketKey] = bucket
+	}
+
+	bucket.tokens = math.Min(
+		float64(limit.Burst),
+		bucket.tokens+now.Sub(bucket.last).Seconds()*limit.PerSec,
+	)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+
+	return true
+}
+
+//
+// sweep forgets about buckets that would have refilled completely by now, since a brand new bucket
+// would behave identically. This keeps buckets of disconnected clients from piling up. The caller
+// must hold the rate limiter's lock.
+//
+func (o *RateLimiter) sweep(now time.Time) {
+	if now.Sub(o.lastSweep) < rateSweepInterval {
+		return
+	}
+
+	o.lastSweep = now
+
+	for bucketKey, bucket := range o.buckets {
+		limit, prs := o.keyLimits[bucketKey.key]
+		if !prs {
+			limit = o.def
+		}
+
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*limit.PerSec >= float64(limit.Burst) {
+			delete(o.buckets, bucketKey)
+		}
+	}
+}
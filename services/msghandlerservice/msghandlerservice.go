@@ -4,10 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"reflect"
+	"runtime/debug"
 	"sync"
 
 	"github.com/lukehollenback/arcane-server/models"
 	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/mitchellh/mapstructure"
 )
 
 var (
@@ -15,19 +19,79 @@ var (
 	once sync.Once
 )
 
+var (
+	clientPtrType = reflect.TypeOf(new(models.Client))
+	msgPtrType    = reflect.TypeOf(new(msgmodels.Msg))
+	errorType     = reflect.TypeOf(new(error)).Elem()
+)
+
 //
 // MsgHandlerService represents an instance of the message handler service.
 //
 type MsgHandlerService struct {
-	handlers map[string]*registeredMsgHandler
+	config      *Config                          // Structure with the service's configuration parameters.
+	handlers    map[string]*registeredMsgHandler // Table of registered message handlers keyed by message type key.
+	middlewares []Middleware                     // Middlewares that every message passes through, outermost first.
+	chain       Handler                          // The middlewares composed around the dispatch to the registered message handlers.
+	panicsMu    *sync.Mutex                      // Mutex to protect against concurrent access to the panic counters.
+	panics      map[string]int64                 // Number of panics that have been recovered from keyed by message type key.
+}
+
+//
+// Config represents a struct of configuration settings for the message handler service.
+//
+type Config struct {
+	Send       func(*models.Client, *msgmodels.Msg) // Sends a message to a client. Used to reply to requests that could not be handled.
+	Disconnect func(*models.Client, string)         // Disconnects a client for the provided reason. Used to get rid of clients whose messages keep causing panics.
+	MaxPanics  int                                  // Number of panics a client's messages may cause before it is disconnected. Zero disables disconnecting.
 }
 
+//
+// Handler represents a function that handles a message recieved from a client.
+//
+type Handler func(client *models.Client, msg *msgmodels.Msg) error
+
+//
+// Middleware represents a function that wraps a Handler with some cross-cutting behavior (e.g.
+// logging). The returned Handler decides whether, and how, to call the next one in the chain.
+//
+type Middleware func(next Handler) Handler
+
 //
 // registeredMsgHandler represents a registered message handler.
 //
 type registeredMsgHandler struct {
-	requiresAuth bool                                       // Whether or not the client must be authenticated in order for the message to be handled.
-	callback     func(*models.Client, *msgmodels.Msg) error // The actual handler method to execute upon recieving the message.
+	requiresAuth bool    // Whether or not the client must be authenticated in order for the message to be handled.
+	callback     Handler // The actual handler method to execute upon recieving the message.
+}
+
+//
+// Error represents a failure to handle a message along with a code (e.g. "authRequired")
+// categorizing it. The code is passed along to the client in the "Error"-type message that is sent
+// back to it. Errors that are not of this type are categorized as "handlerFailed".
+//
+type Error struct {
+	Code string // A machine-readable code categorizing the error. See the "ErrCode*" constants in msgmodels.
+	Err  error  // The underlying error.
+}
+
+//
+// Error implements the error interface.
+//
+func (o *Error) Error() string {
+	return o.Err.Error()
+}
+
+//
+// ErrorCode returns the code categorizing the provided error that occurred while handling a
+// message.
+//
+func ErrorCode(err error) string {
+	if handlerErr, ok := err.(*Error); ok {
+		return handlerErr.Code
+	}
+
+	return msgmodels.ErrCodeHandlerFailed
 }
 
 //
@@ -37,11 +101,22 @@ func Instance() *MsgHandlerService {
 	once.Do(func() {
 		o = new(MsgHandlerService)
 		o.handlers = make(map[string]*registeredMsgHandler)
+		o.chain = o.buildChain()
+		o.panicsMu = &sync.Mutex{}
+		o.panics = make(map[string]int64)
 	})
 
 	return o
 }
 
+//
+// Config allows for the message handler service to be configured. Until it is, requests that can
+// not be handled are only reported back to the caller of ExecuteMsgHandler(), and not to the client.
+//
+func (o *MsgHandlerService) Config(config *Config) {
+	o.config = config
+}
+
 //
 // RegisterMsgHandler registers a handler function to be executed when messages of the specified key
 // are recieved from clients.
@@ -49,7 +124,7 @@ func Instance() *MsgHandlerService {
 func (o *MsgHandlerService) RegisterMsgHandler(
 	key string,
 	requiresAuth bool,
-	callback func(*models.Client, *msgmodels.Msg) error,
+	callback Handler,
 ) {
 	o.handlers[key] = &registeredMsgHandler{
 		requiresAuth: requiresAuth,
@@ -60,28 +135,265 @@ func (o *MsgHandlerService) RegisterMsgHandler(
 }
 
 //
-// ExecuteMsgHandler attempts to execute the appropriate registered handler function for the
-// provided message.
+// RegisterTypedMsgHandler registers a handler function to be executed when messages are recieved
+// from clients, deriving the message type key from the type of the handler's data payload. The
+// callback must have the signature "func(*models.Client, *msgmodels.Msg, *T) error", where T is the
+// struct (e.g. msgmodels.Chat) whose name is the message type key. Before the callback is executed,
+// the message's data payload is strictly decoded into a new T – unknown fields and values of the
+// wrong type are rejected, and the callback is never executed for such messages.
 //
-func (o *MsgHandlerService) ExecuteMsgHandler(client *models.Client, msg *msgmodels.Msg) error {
+// NOTE: This panics if the callback does not have the required signature. Handlers are registered
+//  while the program is initializing, so such a mistake will be caught immediately.
+//
+func (o *MsgHandlerService) RegisterTypedMsgHandler(requiresAuth bool, callback interface{}) {
 	//
-	// Attempt to retrieve the handler callback from the map of those that are registered.
+	// Make sure that the callback has the right signature, and pull the payload type out of it.
 	//
-	handler, prs := o.handlers[msg.Key]
+	fn := reflect.ValueOf(callback)
+	fnType := fn.Type()
 
-	if !prs {
-		return fmt.Errorf("no message handler is known for the message type key \"%s\"", msg.Key)
+	if fnType.Kind() != reflect.Func ||
+		fnType.NumIn() != 3 ||
+		fnType.In(0) != clientPtrType ||
+		fnType.In(1) != msgPtrType ||
+		fnType.In(2).Kind() != reflect.Ptr ||
+		fnType.In(2).Elem().Kind() != reflect.Struct ||
+		fnType.NumOut() != 1 ||
+		fnType.Out(0) != errorType {
+		panic(fmt.Sprintf(
+			"message handler callbacks must have the signature "+
+				"\"func(*models.Client, *msgmodels.Msg, *T) error\", not \"%s\"",
+			fnType,
+		))
 	}
 
+	payloadType := fnType.In(2).Elem()
+	key := payloadType.Name()
+
 	//
-	// Make sure the client has authenticated already if the handler requires it.
+	// Register a handler that decodes the payload and then passes it along to the callback.
 	//
-	if handler.requiresAuth && !client.Authed() {
-		return errors.New("message handling requires authentication")
+	o.RegisterMsgHandler(key, requiresAuth, func(client *models.Client, msg *msgmodels.Msg) error {
+		data := reflect.New(payloadType)
+
+		if err := decodePayload(msg.Data, data.Interface()); err != nil {
+			return &Error{
+				Code: msgmodels.ErrCodeBadPayload,
+				Err:  fmt.Errorf("the data payload of the \"%s\" message is malformed (%s)", key, err),
+			}
+		}
+
+		out := fn.Call([]reflect.Value{reflect.ValueOf(client), reflect.ValueOf(msg), data})
+
+		err, _ := out[0].Interface().(error)
+
+		return err
+	})
+}
+
+//
+// Use adds the provided middleware to the chain that every message passes through. Middlewares are
+// called in the order that they were added, so the first one added sees each message first. The
+// built-in Recover and Auth middlewares always sit innermost (i.e. after all of these), right
+// before the registered message handler is called, so that panics are reported to every middleware
+// as errors and so that authentication can not be bypassed by forgetting to add it.
+//
+// NOTE: It is up to the caller to execute this method before any messages are handled. Failing to
+//  do so may result in a corrupt program state.
+//
+func (o *MsgHandlerService) Use(middleware Middleware) {
+	o.middlewares = append(o.middlewares, middleware)
+	o.chain = o.buildChain()
+}
+
+//
+// RequiresAuth returns whether or not the handler registered for the specified message type key
+// requires that the client be authenticated. Unknown keys do not.
+//
+func (o *MsgHandlerService) RequiresAuth(key string) bool {
+	handler, prs := o.handlers[key]
+
+	return prs && handler.requiresAuth
+}
+
+//
+// ExecuteMsgHandler passes the provided message through the middleware chain to the appropriate
+// registered handler function. If the message can not be handled, an "Error"-type message carrying
+// the same identifier as the provided message is sent back to the client and the error is
+// returned. Clients whose messages keep causing panics are disconnected afterwards.
+//
+func (o *MsgHandlerService) ExecuteMsgHandler(client *models.Client, msg *msgmodels.Msg) error {
+	err := o.executeChain(client, msg)
+	if err != nil {
+		o.replyError(client, msg, err)
+	}
+
+	if ErrorCode(err) == msgmodels.ErrCodeInternal {
+		o.disconnectIfPanicky(client)
+	}
+
+	return err
+}
+
+//
+// Panics returns the number of panics that have been recovered from so far keyed by the message
+// type key of the message that was being handled at the time.
+//
+func (o *MsgHandlerService) Panics() map[string]int64 {
+	o.panicsMu.Lock()
+	defer o.panicsMu.Unlock()
+
+	panics := make(map[string]int64, len(o.panics))
+
+	for key, count := range o.panics {
+		panics[key] = count
+	}
+
+	return panics
+}
+
+//
+// executeChain passes the provided message through the middleware chain. Panics further down the
+// chain are recovered from by the built-in Recover middleware, but one that occurs in a middleware
+// added with Use() is recovered from here as a last resort.
+//
+func (o *MsgHandlerService) executeChain(client *models.Client, msg *msgmodels.Msg) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = o.recordPanic(client, msg, r)
+		}
+	}()
+
+	return o.chain(client, msg)
+}
+
+//
+// recordPanic logs a panic that occurred while handling the provided message (along with the stack
+// trace) and counts it against both the message's type key and the client. Returns the error that
+// the message should be considered to have failed with.
+//
+func (o *MsgHandlerService) recordPanic(
+	client *models.Client,
+	msg *msgmodels.Msg,
+	recovered interface{},
+) error {
+	log.Printf(
+		"%sRecovered from a panic while handling a \"%s\" message. (Panic: %v)\n%s",
+		client.LogPrefix(), msg.Key, recovered, debug.Stack(),
+	)
+
+	o.panicsMu.Lock()
+
+	o.panics[msg.Key]++
+
+	o.panicsMu.Unlock()
+
+	client.IncPanics()
+
+	return &Error{
+		Code: msgmodels.ErrCodeInternal,
+		Err:  errors.New("an internal error occurred while handling the message"),
+	}
+}
+
+//
+// disconnectIfPanicky disconnects the provided client if its messages have caused too many panics.
+//
+func (o *MsgHandlerService) disconnectIfPanicky(client *models.Client) {
+	if o.config == nil || o.config.Disconnect == nil || o.config.MaxPanics <= 0 {
+		return
+	}
+
+	panics := client.Panics()
+	if panics < o.config.MaxPanics {
+		return
+	}
+
+	log.Printf("%sDisconnecting client after %d panics.", client.LogPrefix(), panics)
+
+	o.config.Disconnect(client, "Too many of your messages caused server errors.")
+}
+
+//
+// buildChain composes the registered middlewares, followed by the built-in Recover and Auth
+// middlewares, around the dispatch to the registered message handlers.
+//
+func (o *MsgHandlerService) buildChain() Handler {
+	chain := Recover(Auth(o.dispatch))
+
+	for i := len(o.middlewares) - 1; i >= 0; i-- {
+		chain = o.middlewares[i](chain)
+	}
+
+	return chain
+}
+
+//
+// dispatch actually executes the appropriate registered handler function for the provided message.
+// It sits at the very end of the middleware chain.
+//
+func (o *MsgHandlerService) dispatch(client *models.Client, msg *msgmodels.Msg) error {
+	handler, prs := o.handlers[msg.Key]
+	if !prs {
+		return &Error{
+			Code: msgmodels.ErrCodeUnknownKey,
+			Err:  fmt.Errorf("no message handler is known for the message type key \"%s\"", msg.Key),
+		}
 	}
 
-	//
-	// Execute the handler callback.
-	//
 	return handler.callback(client, msg)
 }
+
+//
+// replyError sends an "Error"-type message describing why the provided message could not be
+// handled back to the client that sent it.
+//
+func (o *MsgHandlerService) replyError(client *models.Client, msg *msgmodels.Msg, err error) {
+	if o.config == nil || o.config.Send == nil {
+		return
+	}
+
+	errMsgData := &msgmodels.Error{
+		Code:    ErrorCode(err),
+		Message: err.Error(),
+		Key:     msg.Key,
+	}
+	errMsg := msgmodels.CreateReply(msg, errMsgData)
+
+	o.config.Send(client, errMsg)
+}
+
+//
+// decodePayload strictly decodes the provided generic data payload of a message into the provided
+// struct pointer. Unlike a plain mapstructure.Decode(), keys that do not correspond to any field
+// and fractional numbers destined for integer fields are rejected rather than ignored or truncated.
+//
+func decodePayload(data interface{}, result interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ErrorUnused: true,
+		DecodeHook:  rejectFractionalInts,
+		Result:      result,
+	})
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(data)
+}
+
+//
+// rejectFractionalInts is a mapstructure decode hook that refuses to decode numbers with a
+// fractional part into integer fields. Numbers arrive from the JSON codec as float64s, and would
+// otherwise be silently truncated.
+//
+func rejectFractionalInts(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	switch to.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f, ok := data.(float64); ok && f != math.Trunc(f) {
+			return nil, fmt.Errorf("expected an integer but got %v", f)
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,65 @@
+package msghandlerservice
+
+import (
+	"testing"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/transport"
+)
+
+func TestPanicsAreSeenByMiddlewaresAndDisconnectRepeatOffenders(t *testing.T) {
+	events := make([]string, 0)
+
+	Instance().RegisterMsgHandler("Boom", false, func(client *models.Client, msg *msgmodels.Msg) error {
+		panic("kaboom")
+	})
+
+	Instance().Config(&Config{
+		Send: func(client *models.Client, msg *msgmodels.Msg) {
+			events = append(events, "send "+msg.Key+" "+msg.ID)
+		},
+		Disconnect: func(client *models.Client, reason string) {
+			events = append(events, "disconnect")
+		},
+		MaxPanics: 2,
+	})
+
+	metrics := CreateMetrics()
+
+	Instance().Use(metrics.Middleware)
+
+	client := models.CreateClient(transport.CreatePipeConn(1))
+
+	for _, id := range []string{"1", "2"} {
+		msg := &msgmodels.Msg{Key: "Boom", ID: id}
+
+		if err := Instance().ExecuteMsgHandler(client, msg); ErrorCode(err) != msgmodels.ErrCodeInternal {
+			t.Fatalf("Expected an internal error. (Error: %v)", err)
+		}
+	}
+
+	if got := metrics.Snapshot()["Boom"]; got.Count != 2 || got.Errors != 2 {
+		t.Errorf("Expected the metrics middleware to see both failures. (Metrics: %+v)", got)
+	}
+
+	if got := Instance().Panics()["Boom"]; got != 2 {
+		t.Errorf("Expected 2 panics to be counted for the key. (Panics: %d)", got)
+	}
+
+	if got := client.Panics(); got != 2 {
+		t.Errorf("Expected 2 panics to be counted for the client. (Panics: %d)", got)
+	}
+
+	expected := []string{"send Error 1", "send Error 2", "disconnect"}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Unexpected events. (Events: %q)", events)
+	}
+
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Fatalf("Unexpected events. (Events: %q)", events)
+		}
+	}
+}
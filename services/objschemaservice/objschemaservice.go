@@ -0,0 +1,210 @@
+package objschemaservice
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/lukehollenback/arcane-server/util"
+)
+
+const (
+	//
+	// VarInt is the type of synchronized variables holding whole numbers.
+	//
+	VarInt = "int"
+
+	//
+	// VarFloat is the type of synchronized variables holding real numbers.
+	//
+	VarFloat = "float"
+
+	//
+	// VarString is the type of synchronized variables holding free-form text.
+	//
+	VarString = "string"
+
+	//
+	// VarBool is the type of synchronized variables holding true/false flags.
+	//
+	VarBool = "bool"
+
+	//
+	// VarEnum is the type of synchronized variables holding one of a fixed set of strings.
+	//
+	VarEnum = "enum"
+)
+
+var (
+	o    *ObjSchemaService
+	once sync.Once
+)
+
+//
+// ObjSchemaService represents an instance of the object schema service, which knows which
+// synchronized variables each type of object is allowed to have.
+//
+type ObjSchemaService struct {
+	mu      *sync.RWMutex     // Mutex to protect against concurrent modification of the schema table.
+	schemas map[string]Schema // Table of registered schemas keyed by the object type (e.g. "oPlayer") that they describe.
+}
+
+//
+// Schema represents the set of synchronized variables that a type of object is allowed to have,
+// keyed by variable name.
+//
+type Schema map[string]*VarSchema
+
+//
+// VarSchema describes a single synchronized variable.
+//
+// NOTE: Only the members relevant to the variable's type are consulted.
+//
+type VarSchema struct {
+	Type   string   // The type of the variable (e.g. "int").
+	Min    float64  // The minimum allowed value of numeric variables.
+	Max    float64  // The maximum allowed value of numeric variables.
+	MaxLen int      // The maximum allowed length of string variables. Zero for no limit.
+	Values []string // The allowed values of enum variables.
+}
+
+//
+// Instance provides a singleton instance of the object schema service.
+//
+func Instance() *ObjSchemaService {
+	once.Do(func() {
+		o = &ObjSchemaService{
+			mu:      &sync.RWMutex{},
+			schemas: make(map[string]Schema),
+		}
+	})
+
+	return o
+}
+
+//
+// RegisterSchema registers the schema of the synchronized variables that objects of the specified
+// type are allowed to have.
+//
+func (o *ObjSchemaService) RegisterSchema(objType string, schema Schema) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.schemas[objType] = schema
+
+	log.Printf("Registered new synchronized variable schema for the object type \"%s\".", objType)
+}
+
+//
+// Validate checks the provided synchronized variables against the schema registered for the
+// specified object type. Variables that the schema does not know about are dropped, and those that
+// it does know about are coerced into their declared types. If any known variable is malformed or
+// out of range, the entire payload is rejected with an error.
+//
+func (o *ObjSchemaService) Validate(objType string, vars map[string]interface{}) (map[string]interface{}, error) {
+	o.mu.RLock()
+	schema, prs := o.schemas[objType]
+	o.mu.RUnlock()
+
+	if !prs {
+		return nil, fmt.Errorf("no synchronized variable schema is known for the object type \"%s\"", objType)
+	}
+
+	valid := make(map[string]interface{}, len(vars))
+
+	for key, val := range vars {
+		varSchema, prs := schema[key]
+		if !prs {
+			continue
+		}
+
+		coerced, err := varSchema.coerce(val)
+		if err != nil {
+			return nil, fmt.Errorf("synchronized variable \"%s\" is malformed: %s", key, err)
+		}
+
+		valid[key] = coerced
+	}
+
+	return valid, nil
+}
+
+//
+// coerce attempts to turn the provided value into a legitimate value of the variable.
+//
+func (o *VarSchema) coerce(val interface{}) (interface{}, error) {
+	switch o.Type {
+	case VarInt:
+		num, ok := toFloat(val)
+		if !ok || num != math.Trunc(num) {
+			return nil, fmt.Errorf("expected a whole number but got %v", val)
+		}
+
+		if num < o.Min || num > o.Max {
+			return nil, fmt.Errorf("%v is outside of the range [%v, %v]", val, o.Min, o.Max)
+		}
+
+		return int(num), nil
+
+	case VarFloat:
+		num, ok := toFloat(val)
+		if !ok || math.IsNaN(num) || math.IsInf(num, 0) {
+			return nil, fmt.Errorf("expected a number but got %v", val)
+		}
+
+		if num < o.Min || num > o.Max {
+			return nil, fmt.Errorf("%v is outside of the range [%v, %v]", val, o.Min, o.Max)
+		}
+
+		return num, nil
+
+	case VarString:
+		str, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string but got %v", val)
+		}
+
+		if o.MaxLen > 0 && len(str) > o.MaxLen {
+			return nil, fmt.Errorf("string is longer than %d bytes", o.MaxLen)
+		}
+
+		return str, nil
+
+	case VarBool:
+		b, ok := val.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean but got %v", val)
+		}
+
+		return b, nil
+
+	case VarEnum:
+		str, ok := val.(string)
+		if !ok || !util.SliceContainsString(str, o.Values) {
+			return nil, fmt.Errorf("expected one of %v but got %v", o.Values, val)
+		}
+
+		return str, nil
+
+	default:
+		return nil, fmt.Errorf("the schema declares unknown type \"%s\"", o.Type)
+	}
+}
+
+//
+// toFloat attempts to interpret the provided value (which may have been deserialized as any one of
+// a number of numeric types) as a float.
+//
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	default:
+		i, ok := util.GetIntVal(val)
+
+		return float64(i), ok
+	}
+}
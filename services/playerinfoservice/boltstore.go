@@ -0,0 +1,107 @@
+package playerinfoservice
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+//
+// playersBucket is the name of the bucket that player records are kept in.
+//
+var playersBucket = []byte("players")
+
+//
+// BoltStore is a player record store backed by an embedded, on-disk BoltDB database file.
+//
+type BoltStore struct {
+	path string   // Path to the database file.
+	db   *bolt.DB // Handle to the open database. Nil until the store has been opened.
+}
+
+//
+// CreateBoltStore constructs a new BoltDB-backed store that will keep its records in the file at
+// the provided path.
+//
+func CreateBoltStore(path string) *BoltStore {
+	return &BoltStore{
+		path: path,
+	}
+}
+
+//
+// Open implements the method defined by the Store interface.
+//
+func (o *BoltStore) Open() error {
+	db, err := bolt.Open(o.path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(playersBucket)
+
+		return err
+	})
+	if err != nil {
+		db.Close()
+
+		return err
+	}
+
+	o.db = db
+
+	return nil
+}
+
+//
+// Close implements the method defined by the Store interface.
+//
+func (o *BoltStore) Close() error {
+	if o.db == nil {
+		return nil
+	}
+
+	err := o.db.Close()
+	o.db = nil
+
+	return err
+}
+
+//
+// Load implements the method defined by the Store interface.
+//
+func (o *BoltStore) Load(id string) (*Player, error) {
+	var player *Player
+
+	err := o.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(playersBucket).Get([]byte(id))
+		if raw == nil {
+			return ErrPlayerNotFound
+		}
+
+		player = new(Player)
+
+		return json.Unmarshal(raw, player)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return player, nil
+}
+
+//
+// Save implements the method defined by the Store interface.
+//
+func (o *BoltStore) Save(player *Player) error {
+	raw, err := json.Marshal(player)
+	if err != nil {
+		return err
+	}
+
+	return o.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(playersBucket).Put([]byte(player.ID), raw)
+	})
+}
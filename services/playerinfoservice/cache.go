@@ -0,0 +1,106 @@
+package playerinfoservice
+
+import "container/list"
+
+//
+// cacheEntry represents a single player record held by the cache.
+//
+type cacheEntry struct {
+	player *Player // The cached record.
+	dirty  bool    // Whether or not the record has been modified since it was last saved to the store.
+}
+
+//
+// lruCache is a fixed-capacity, least-recently-used cache of player records. It is NOT safe for
+// concurrent use – it is up to the owner to synchronize access to it.
+//
+type lruCache struct {
+	capacity int                      // Maximum number of records to hold before evicting.
+	order    *list.List               // Records ordered from most to least recently used.
+	entries  map[string]*list.Element // Table of list elements keyed by player ID.
+}
+
+//
+// createLRUCache constructs a new, empty cache that holds at most the specified number of records.
+//
+func createLRUCache(capacity int) *lruCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &lruCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+//
+// get retrieves the cached entry for the specified player, marking it as most recently used.
+//
+func (o *lruCache) get(id string) (*cacheEntry, bool) {
+	elem, prs := o.entries[id]
+	if !prs {
+		return nil, false
+	}
+
+	o.order.MoveToFront(elem)
+
+	return elem.Value.(*cacheEntry), true
+}
+
+//
+// victim returns the entry that would be evicted if an entry for the specified player were put into
+// the cache, or nil if nothing would be.
+//
+func (o *lruCache) victim(id string) *cacheEntry {
+	if _, prs := o.entries[id]; prs || o.order.Len() < o.capacity {
+		return nil
+	}
+
+	return o.order.Back().Value.(*cacheEntry)
+}
+
+//
+// put adds (or replaces) the cached entry for the provided player. If doing so causes the cache to
+// exceed its capacity, the least recently used entry (see victim()) is evicted and returned.
+//
+func (o *lruCache) put(entry *cacheEntry) *cacheEntry {
+	if elem, prs := o.entries[entry.player.ID]; prs {
+		elem.Value = entry
+		o.order.MoveToFront(elem)
+
+		return nil
+	}
+
+	o.entries[entry.player.ID] = o.order.PushFront(entry)
+
+	if o.order.Len() <= o.capacity {
+		return nil
+	}
+
+	oldest := o.order.Back()
+	evicted := oldest.Value.(*cacheEntry)
+
+	o.order.Remove(oldest)
+	delete(o.entries, evicted.player.ID)
+
+	return evicted
+}
+
+//
+// dirtyEntries returns all cached entries that have been modified since they were last saved.
+//
+func (o *lruCache) dirtyEntries() []*cacheEntry {
+	dirty := make([]*cacheEntry, 0)
+
+	for elem := o.order.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*cacheEntry)
+
+		if entry.dirty {
+			dirty = append(dirty, entry)
+		}
+	}
+
+	return dirty
+}
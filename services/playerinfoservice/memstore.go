@@ -0,0 +1,63 @@
+package playerinfoservice
+
+import "sync"
+
+//
+// MemStore is a player record store that only keeps records in memory. Records do not survive a
+// restart, so it is only really useful for tests and throwaway local servers.
+//
+type MemStore struct {
+	mu      *sync.Mutex        // Mutex to protect against concurrent modification of the record table.
+	players map[string]*Player // Table of player records keyed by their player ID.
+}
+
+//
+// CreateMemStore constructs a new, empty, in-memory store.
+//
+func CreateMemStore() *MemStore {
+	return &MemStore{
+		mu:      &sync.Mutex{},
+		players: make(map[string]*Player),
+	}
+}
+
+//
+// Open implements the method defined by the Store interface.
+//
+func (o *MemStore) Open() error {
+	return nil
+}
+
+//
+// Close implements the method defined by the Store interface.
+//
+func (o *MemStore) Close() error {
+	return nil
+}
+
+//
+// Load implements the method defined by the Store interface.
+//
+func (o *MemStore) Load(id string) (*Player, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	player, prs := o.players[id]
+	if !prs {
+		return nil, ErrPlayerNotFound
+	}
+
+	return player.copy(), nil
+}
+
+//
+// Save implements the method defined by the Store interface.
+//
+func (o *MemStore) Save(player *Player) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.players[player.ID] = player.copy()
+
+	return nil
+}
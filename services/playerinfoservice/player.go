@@ -0,0 +1,59 @@
+package playerinfoservice
+
+import (
+	"time"
+
+	"github.com/lukehollenback/arcane-server/util"
+)
+
+const (
+	//
+	// RolePlayer is the role held by every player.
+	//
+	RolePlayer = "player"
+
+	//
+	// RoleModerator is the role held by players that are allowed to moderate the game world.
+	//
+	RoleModerator = "moderator"
+
+	//
+	// RoleAdmin is the role held by players that are allowed to administer the server.
+	//
+	RoleAdmin = "admin"
+)
+
+//
+// Player represents the persisted record of a single player.
+//
+// NOTE: We intentionally make all members of this class public to help with both serialization and
+//  with logging.
+//
+type Player struct {
+	ID          string    // The unique identifier of the player. Matches the Player ID of authenticated clients.
+	Username    string    // The display name of the player.
+	CreatedAt   time.Time // When the player first logged in.
+	LastLoginAt time.Time // When the player most recently logged in.
+	LastAreaID  string    // The unique ID of the area that the player was last in.
+	LastX       int       // The horizontal location that the player was last at.
+	LastY       int       // The vertical location that the player was last at.
+	Roles       []string  // The roles (e.g. "moderator") that have been granted to the player.
+}
+
+//
+// HasRole checks whether or not the player has been granted the specified role.
+//
+func (o *Player) HasRole(role string) bool {
+	return util.SliceContainsString(role, o.Roles)
+}
+
+//
+// copy creates a deep copy of the player record so that it can be handed out without exposing the
+// cached instance to concurrent modification.
+//
+func (o *Player) copy() *Player {
+	cp := *o
+	cp.Roles = append([]string(nil), o.Roles...)
+
+	return &cp
+}
@@ -1,8 +1,12 @@
 package playerinfoservice
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"time"
 )
 
 var (
@@ -15,8 +19,21 @@ var (
 // access to data (e.g. usernames) about players.
 //
 type PlayerInfoService struct {
-	// TODO: Currently this service is not stateful. However, in the future, it will need to maintain
-	//  connections to databases and caches of player info.
+	mu        *sync.Mutex                // Mutex to protect against concurrent access to the cache, store, and username index.
+	config    *Config                    // Structure with the service's configuration parameters.
+	cache     *lruCache                  // Cache of recently used player records that sits in front of the store.
+	usernames map[string]map[string]bool // Index of the sets of player IDs holding each lowercased username. Only covers players that have logged in.
+	chKill    chan bool                  // Channel that can be used to send a kill signal to the periodic flushing goroutine.
+	chStopped chan bool                  // Channel that the periodic flushing goroutine will send a message on once it has stopped.
+}
+
+//
+// Config represents a struct of configuration settings for the Player Info Service.
+//
+type Config struct {
+	Store             Store // The backend that player records are persisted in.
+	CacheSize         int   // Maximum number of player records to keep cached in memory.
+	FlushIntervalSecs int   // How often modified records are saved to the store. Zero to only save them on eviction and shut-down.
 }
 
 //
@@ -24,18 +41,57 @@ type PlayerInfoService struct {
 //
 func Instance() *PlayerInfoService {
 	once.Do(func() {
-		o = &PlayerInfoService{}
+		o = &PlayerInfoService{
+			mu:        &sync.Mutex{},
+			usernames: make(map[string]map[string]bool),
+		}
 	})
 
 	return o
 }
 
+//
+// Config allows for the Player Info Service to be configured. It is up to the caller to execute
+// this method when the service is NOT running. Failing to do so may result in a corrupt program
+// state.
+//
+func (o *PlayerInfoService) Config(config *Config) {
+	o.config = config
+}
+
 //
 // Start implements the method defined by the services.Stop() interface.
 //
 func (o *PlayerInfoService) Start() (<-chan bool, error) {
 	log.Printf("The Player Info Service is starting...")
 
+	if o.config == nil || o.config.Store == nil {
+		return nil, errors.New("no player record store has been configured")
+	}
+
+	//
+	// Open the underlying store and (re)-initialize the cache and username index in front of it.
+	//
+	if err := o.config.Store.Open(); err != nil {
+		return nil, err
+	}
+
+	o.cache = createLRUCache(o.config.CacheSize)
+	o.usernames = make(map[string]map[string]bool)
+
+	//
+	// Periodically save modified records so that a crash does not lose every session since start-up.
+	//
+	o.chKill = nil
+	o.chStopped = nil
+
+	if o.config.FlushIntervalSecs > 0 {
+		o.chKill = make(chan bool)
+		o.chStopped = make(chan bool)
+
+		go o.flushPeriodically()
+	}
+
 	ch := make(chan bool, 1)
 
 	ch <- true
@@ -44,11 +100,38 @@ func (o *PlayerInfoService) Start() (<-chan bool, error) {
 }
 
 //
-// Stop implements the method defined by the services.Stop() interface.
+// Stop implements the method defined by the services.Stop() interface. The store is always closed,
+// even if some of the modified records could not be saved to it.
 //
 func (o *PlayerInfoService) Stop() (<-chan bool, error) {
 	log.Printf("The Player Info Service is stopping...")
 
+	//
+	// Kill the periodic flushing goroutine (if it is running). We must wait for it to gracefully
+	// stop.
+	//
+	if o.chKill != nil {
+		o.chKill <- true
+
+		<-o.chStopped
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	//
+	// Flush any modified records that are still sitting in the cache, then close the store.
+	//
+	errs := o.flushLocked()
+
+	if err := o.config.Store.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return nil, combineErrors(errs)
+	}
+
 	ch := make(chan bool, 1)
 
 	ch <- true
@@ -58,8 +141,289 @@ func (o *PlayerInfoService) Stop() (<-chan bool, error) {
 
 //
 // GetUsername retrieves (e.g. from database or cache) the username of the player with the specified
-//  player ID.
+// player ID. If the player is not known, their player ID is returned instead.
 //
 func (o *PlayerInfoService) GetUsername(playerID string) string {
-	return "Zaedaux"
+	player, err := o.GetPlayer(playerID)
+	if err != nil || len(player.Username) == 0 {
+		return playerID
+	}
+
+	return player.Username
+}
+
+//
+// FindPlayerID looks up the player ID of the player with the specified username, ignoring case.
+// Only players that have logged in since the service was started can be found. If no such player is
+// known, ErrPlayerNotFound is returned. Usernames are not guaranteed to be unique, so if more than
+// one known player holds the username, ErrUsernameAmbiguous is returned rather than guessing.
+//
+func (o *PlayerInfoService) FindPlayerID(username string) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	playerIDs := o.usernames[strings.ToLower(username)]
+
+	switch len(playerIDs) {
+	case 0:
+		return "", ErrPlayerNotFound
+	case 1:
+		for playerID := range playerIDs {
+			return playerID, nil
+		}
+	}
+
+	return "", ErrUsernameAmbiguous
+}
+
+//
+// Authorized checks whether or not the player with the specified player ID holds the specified role.
+// Administrators are considered to hold every role, and everybody holds the empty role.
+//
+func (o *PlayerInfoService) Authorized(playerID string, role string) bool {
+	if len(role) == 0 {
+		return true
+	}
+
+	player, err := o.GetPlayer(playerID)
+	if err != nil {
+		return false
+	}
+
+	return player.HasRole(role) || player.HasRole(RoleAdmin)
+}
+
+//
+// GetPlayer retrieves (e.g. from database or cache) a copy of the record of the player with the
+// specified player ID.
+//
+func (o *PlayerInfoService) GetPlayer(playerID string) (*Player, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry, err := o.load(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.player.copy(), nil
+}
+
+//
+// RecordLogin notes that the player with the specified player ID has just logged in, creating a
+// brand new record for them if this is their first time. If a non-empty username is provided, it
+// replaces the one on record. The provided roles (as granted by whoever issued the player's
+// credentials) replace the ones on record as well, so that revoking a role takes effect on the next
+// login. Every player always holds RolePlayer. A copy of the up-to-date record is returned.
+//
+func (o *PlayerInfoService) RecordLogin(
+	playerID string,
+	username string,
+	roles []string,
+) (*Player, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+
+	entry, err := o.load(playerID)
+
+	switch {
+	case err == ErrPlayerNotFound:
+		entry = &cacheEntry{
+			player: &Player{
+				ID:        playerID,
+				Username:  playerID,
+				CreatedAt: now,
+				Roles:     []string{RolePlayer},
+			},
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	prevUsername := entry.player.Username
+
+	if len(username) > 0 {
+		entry.player.Username = username
+	}
+
+	entry.player.Roles = []string{RolePlayer}
+
+	for _, role := range roles {
+		if len(role) > 0 && !entry.player.HasRole(role) {
+			entry.player.Roles = append(entry.player.Roles, role)
+		}
+	}
+
+	entry.player.LastLoginAt = now
+	entry.dirty = true
+
+	if err := o.addToCache(entry); err != nil {
+		return nil, err
+	}
+
+	o.indexUsername(prevUsername, entry.player)
+
+	return entry.player.copy(), nil
+}
+
+//
+// UpdatePlayer applies the provided mutation to the record of the player with the specified player
+// ID. The modified record will be persisted the next time it is flushed from the cache.
+//
+func (o *PlayerInfoService) UpdatePlayer(playerID string, mutate func(player *Player)) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry, err := o.load(playerID)
+	if err != nil {
+		return err
+	}
+
+	prevUsername := entry.player.Username
+
+	mutate(entry.player)
+
+	entry.dirty = true
+
+	if o.usernames[strings.ToLower(prevUsername)][playerID] {
+		o.indexUsername(prevUsername, entry.player)
+	}
+
+	return nil
+}
+
+//
+// flushPeriodically loops at the configured flush interval and saves any modified records to the
+// store, until it is told to stop. Intended to be run in its own goroutine.
+//
+func (o *PlayerInfoService) flushPeriodically() {
+	log.Printf("Periodic player record flushing has started.")
+
+	ticker := time.NewTicker(time.Duration(o.config.FlushIntervalSecs) * time.Second)
+	defer ticker.Stop()
+
+	for cont := true; cont; {
+		select {
+		case <-o.chKill:
+			cont = false
+		case <-ticker.C:
+			o.mu.Lock()
+
+			errs := o.flushLocked()
+
+			o.mu.Unlock()
+
+			if len(errs) > 0 {
+				log.Printf("Failed to flush player records. (Error: %s)", combineErrors(errs))
+			}
+		}
+	}
+
+	log.Printf("Periodic player record flushing has stopped.")
+
+	o.chStopped <- true
+}
+
+//
+// flushLocked saves every cached record that has been modified since it was last saved. Records
+// that fail to save stay modified so that saving them is attempted again later. It is up to the
+// caller to hold the service's lock.
+//
+func (o *PlayerInfoService) flushLocked() []error {
+	errs := make([]error, 0)
+
+	for _, entry := range o.cache.dirtyEntries() {
+		if err := o.config.Store.Save(entry.player); err != nil {
+			errs = append(errs, fmt.Errorf("player %s: %s", entry.player.ID, err))
+
+			continue
+		}
+
+		entry.dirty = false
+	}
+
+	return errs
+}
+
+//
+// load retrieves the cache entry for the specified player, falling back to the store (and caching
+// the result) if it is not already cached. It is up to the caller to hold the service's lock.
+//
+func (o *PlayerInfoService) load(playerID string) (*cacheEntry, error) {
+	if entry, prs := o.cache.get(playerID); prs {
+		return entry, nil
+	}
+
+	player, err := o.config.Store.Load(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry{
+		player: player,
+	}
+
+	if err := o.addToCache(entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+//
+// addToCache adds the provided entry to the cache. If an entry with unsaved modifications has to be
+// evicted to make room for it, that entry is saved first. Should saving it fail, the cache is left
+// untouched (so that the modifications are not lost) and the error is returned. It is up to the
+// caller to hold the service's lock.
+//
+func (o *PlayerInfoService) addToCache(entry *cacheEntry) error {
+	if victim := o.cache.victim(entry.player.ID); victim != nil && victim.dirty {
+		if err := o.config.Store.Save(victim.player); err != nil {
+			return err
+		}
+
+		victim.dirty = false
+	}
+
+	o.cache.put(entry)
+
+	return nil
+}
+
+//
+// indexUsername adds the provided player to the username index under their current username,
+// dropping them from it under their previous username. It is up to the caller to hold the service's
+// lock.
+//
+func (o *PlayerInfoService) indexUsername(prevUsername string, player *Player) {
+	prevKey := strings.ToLower(prevUsername)
+
+	delete(o.usernames[prevKey], player.ID)
+
+	if len(o.usernames[prevKey]) == 0 {
+		delete(o.usernames, prevKey)
+	}
+
+	key := strings.ToLower(player.Username)
+
+	if _, prs := o.usernames[key]; !prs {
+		o.usernames[key] = make(map[string]bool)
+	}
+
+	o.usernames[key][player.ID] = true
+}
+
+//
+// combineErrors merges the provided errors into a single one that mentions all of them.
+//
+func combineErrors(errs []error) error {
+	msgs := make([]string, len(errs))
+
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Errorf("%d error(s) occurred: %s", len(errs), strings.Join(msgs, "; "))
 }
@@ -0,0 +1,205 @@
+package playerinfoservice
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+//
+// failingStore is an in-memory store whose saves always fail, and which remembers whether or not it
+// has been closed.
+//
+type failingStore struct {
+	*MemStore
+	closed bool
+}
+
+func (o *failingStore) Save(player *Player) error {
+	return errors.New("disk is on fire")
+}
+
+func (o *failingStore) Close() error {
+	o.closed = true
+
+	return nil
+}
+
+//
+// startService (re)-configures and starts the service singleton with the provided settings.
+//
+func startService(t *testing.T, config *Config) *PlayerInfoService {
+	t.Helper()
+
+	Instance().Config(config)
+
+	ch, err := Instance().Start()
+	if err != nil {
+		t.Fatalf("Failed to start the service. (Error: %s)", err)
+	}
+
+	<-ch
+
+	return Instance()
+}
+
+func TestStopSavesModifiedRecords(t *testing.T) {
+	store := CreateMemStore()
+	svc := startService(t, &Config{Store: store, CacheSize: 8})
+
+	if _, err := svc.RecordLogin("p1", "Alice", nil); err != nil {
+		t.Fatalf("Failed to record login. (Error: %s)", err)
+	}
+
+	err := svc.UpdatePlayer("p1", func(player *Player) {
+		player.LastAreaID = "Cave"
+		player.LastX = 0
+		player.LastY = 12
+	})
+	if err != nil {
+		t.Fatalf("Failed to update player. (Error: %s)", err)
+	}
+
+	if _, err := store.Load("p1"); err != ErrPlayerNotFound {
+		t.Fatalf("Expected the record to only be cached before stopping. (Error: %v)", err)
+	}
+
+	if _, err := svc.Stop(); err != nil {
+		t.Fatalf("Failed to stop the service. (Error: %s)", err)
+	}
+
+	player, err := store.Load("p1")
+	if err != nil {
+		t.Fatalf("Expected the record to be saved when stopping. (Error: %s)", err)
+	}
+
+	if player.Username != "Alice" || player.LastAreaID != "Cave" || player.LastX != 0 || player.LastY != 12 {
+		t.Errorf("Saved record does not match. (Record: %+v)", player)
+	}
+}
+
+func TestEvictionSavesModifiedRecords(t *testing.T) {
+	store := CreateMemStore()
+	svc := startService(t, &Config{Store: store, CacheSize: 1})
+
+	svc.RecordLogin("p1", "Alice", nil)
+	svc.RecordLogin("p2", "Bob", nil)
+
+	if _, err := store.Load("p1"); err != nil {
+		t.Errorf("Expected the evicted record to be saved. (Error: %s)", err)
+	}
+
+	if _, err := store.Load("p2"); err != ErrPlayerNotFound {
+		t.Errorf("Expected the cached record to not be saved yet. (Error: %v)", err)
+	}
+
+	svc.Stop()
+}
+
+func TestFailedEvictionKeepsModifiedRecord(t *testing.T) {
+	store := &failingStore{MemStore: CreateMemStore()}
+	svc := startService(t, &Config{Store: store, CacheSize: 1})
+	defer svc.Stop()
+
+	if _, err := svc.RecordLogin("p1", "Alice", nil); err != nil {
+		t.Fatalf("Expected the first login to fit in the cache. (Error: %s)", err)
+	}
+
+	if _, err := svc.RecordLogin("p2", "Bob", nil); err == nil {
+		t.Fatal("Expected the login to fail when the record it would evict cannot be saved.")
+	}
+
+	if player, err := svc.GetPlayer("p1"); err != nil || player.Username != "Alice" {
+		t.Errorf("Expected the unsaved record to stay cached. (Player: %+v, Error: %v)", player, err)
+	}
+
+	if playerID, err := svc.FindPlayerID("alice"); err != nil || playerID != "p1" {
+		t.Errorf("Expected the unsaved record to stay indexed. (Player: %s, Error: %v)", playerID, err)
+	}
+
+	if _, err := svc.FindPlayerID("bob"); err != ErrPlayerNotFound {
+		t.Errorf("Expected the failed login to leave the username index alone. (Error: %v)", err)
+	}
+}
+
+func TestPeriodicFlushSavesModifiedRecords(t *testing.T) {
+	store := CreateMemStore()
+	svc := startService(t, &Config{Store: store, CacheSize: 8, FlushIntervalSecs: 1})
+
+	svc.RecordLogin("p1", "Alice", nil)
+
+	deadline := time.Now().Add(3 * time.Second)
+
+	for {
+		if _, err := store.Load("p1"); err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the record to be saved by the periodic flush.")
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	svc.Stop()
+}
+
+func TestStopClosesStoreDespiteSaveErrors(t *testing.T) {
+	store := &failingStore{MemStore: CreateMemStore()}
+	svc := startService(t, &Config{Store: store, CacheSize: 8})
+
+	svc.RecordLogin("p1", "Alice", nil)
+	svc.RecordLogin("p2", "Bob", nil)
+
+	if _, err := svc.Stop(); err == nil {
+		t.Error("Expected stopping to report the failed saves.")
+	}
+
+	if !store.closed {
+		t.Error("Expected the store to be closed even though saving failed.")
+	}
+}
+
+func TestFindPlayerIDRefusesSharedUsernames(t *testing.T) {
+	svc := startService(t, &Config{Store: CreateMemStore(), CacheSize: 8})
+	defer svc.Stop()
+
+	svc.RecordLogin("p1", "Alice", nil)
+	svc.RecordLogin("p2", "alice", nil)
+
+	if _, err := svc.FindPlayerID("ALICE"); err != ErrUsernameAmbiguous {
+		t.Fatalf("Expected a shared username to be ambiguous. (Error: %v)", err)
+	}
+
+	svc.RecordLogin("p2", "Bob", nil)
+
+	if playerID, err := svc.FindPlayerID("alice"); err != nil || playerID != "p1" {
+		t.Errorf("Expected the username to resolve once it is no longer shared. (Player: %s, Error: %v)", playerID, err)
+	}
+
+	if playerID, err := svc.FindPlayerID("bob"); err != nil || playerID != "p2" {
+		t.Errorf("Expected the new username to resolve. (Player: %s, Error: %v)", playerID, err)
+	}
+}
+
+func TestRecordLoginReplacesRoles(t *testing.T) {
+	svc := startService(t, &Config{Store: CreateMemStore(), CacheSize: 8})
+	defer svc.Stop()
+
+	svc.RecordLogin("p1", "Alice", []string{RoleModerator, RoleModerator, ""})
+
+	if !svc.Authorized("p1", RoleModerator) {
+		t.Fatal("Expected the granted role to be recorded.")
+	}
+
+	player, _ := svc.RecordLogin("p1", "Alice", nil)
+
+	if svc.Authorized("p1", RoleModerator) {
+		t.Error("Expected the revoked role to be dropped on the next login.")
+	}
+
+	if len(player.Roles) != 1 || player.Roles[0] != RolePlayer {
+		t.Errorf("Expected only the role every player holds to remain. (Roles: %q)", player.Roles)
+	}
+}
@@ -0,0 +1,42 @@
+package playerinfoservice
+
+import "errors"
+
+var (
+	//
+	// ErrPlayerNotFound is returned by stores when no record exists for the requested player.
+	//
+	ErrPlayerNotFound = errors.New("no record exists for the player")
+
+	//
+	// ErrUsernameAmbiguous is returned when looking up a player by a username that more than one
+	// player holds.
+	//
+	ErrUsernameAmbiguous = errors.New("more than one player holds the username")
+)
+
+//
+// Store provides a generic interface for backends that are able to persist player records.
+//
+type Store interface {
+	//
+	// Open prepares the store for use (e.g. by opening its underlying database file).
+	//
+	Open() error
+
+	//
+	// Close releases any resources held by the store. Records saved before closing must be durable.
+	//
+	Close() error
+
+	//
+	// Load retrieves the record of the player with the specified ID. If no such record exists,
+	// ErrPlayerNotFound is returned.
+	//
+	Load(id string) (*Player, error)
+
+	//
+	// Save creates or overwrites the record of the provided player.
+	//
+	Save(player *Player) error
+}
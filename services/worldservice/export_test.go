@@ -0,0 +1,21 @@
+package worldservice
+
+import "time"
+
+//
+// StartPaused (re)-initializes the service just like Start() does, but without starting the tick
+// loop, so that tests can advance the world simulation one Tick() at a time instead.
+//
+func (o *WorldService) StartPaused() {
+	o.inputs = make([]*input, 0)
+	o.simulated = make(map[string]Simulated)
+	o.lastTick = time.Now()
+}
+
+//
+// Tick advances the world simulation by a single step, as if the tick loop had ticked at the
+// provided time.
+//
+func (o *WorldService) Tick(now time.Time) {
+	o.tick(now)
+}
@@ -0,0 +1,304 @@
+package worldservice
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+)
+
+var (
+	o    *WorldService
+	once sync.Once
+)
+
+//
+// WorldService represents an instance of the World Service, which is responsible for running the
+// authoritative simulation clock of the game world. Player inputs are queued up as they arrive and
+// then applied, alongside the simulation of server-owned objects, once per tick.
+//
+type WorldService struct {
+	mu        *sync.Mutex          // Mutex to protect against concurrent modification of the input queue and simulated object table.
+	config    *Config              // Structure with the service's configuration parameters.
+	inputs    []*input             // Queue of player inputs received since the last tick.
+	simulated map[string]Simulated // Table of server-owned objects keyed by their unique object identifier.
+	lastTick  time.Time            // When the last tick was run.
+	chKill    chan bool            // Channel that can be used to send a kill signal to the tick loop goroutine.
+	chStopped chan bool            // Channel upon which the tick loop goroutine will send a signal upon completing its shut-down process.
+}
+
+//
+// Config represents a struct of configuration settings for the World Service.
+//
+type Config struct {
+	TickHz int // Number of times per second that the world simulation should be advanced.
+}
+
+//
+// Simulated provides a generic interface for server-owned objects whose state is advanced by the
+// world simulation each tick.
+//
+type Simulated interface {
+	models.Object
+
+	//
+	// Tick advances the object's state by the provided amount of time. It returns any synchronized
+	// variables that changed as a result, which will be sent to all clients in the object's area.
+	//
+	Tick(dt time.Duration) map[string]interface{}
+}
+
+//
+// input represents a single queued player input.
+//
+type input struct {
+	client  *models.Client     // The client that sent the input.
+	objSync *msgmodels.ObjSync // The synchronization request that the client sent.
+}
+
+//
+// delta represents the accumulated changes to a single object over the course of a tick.
+//
+type delta struct {
+	areaID    string                 // The unique ID of the area that the object is in.
+	owner     *models.Client         // The client that owns the object, or nil if it is server-owned.
+	variables map[string]interface{} // The synchronized variables that changed.
+}
+
+//
+// Instance provides a singleton instance of the service.
+//
+func Instance() *WorldService {
+	once.Do(func() {
+		o = &WorldService{
+			mu: &sync.Mutex{},
+		}
+	})
+
+	return o
+}
+
+//
+// Config allows for the World Service to be configured. It is up to the caller to execute this
+// method when the service is NOT running. Failing to do so may result in a corrupt program state.
+//
+func (o *WorldService) Config(config *Config) {
+	o.config = config
+}
+
+//
+// Start implements the method defined by the services.Stop() interface.
+//
+func (o *WorldService) Start() (<-chan bool, error) {
+	log.Printf("The World Service is starting...")
+
+	if o.config == nil || o.config.TickHz <= 0 {
+		return nil, errors.New("the world simulation tick rate must be positive")
+	}
+
+	//
+	// (Re)-initialize some of the service's structures.
+	//
+	o.inputs = make([]*input, 0)
+	o.simulated = make(map[string]Simulated)
+	o.lastTick = time.Now()
+	o.chKill = make(chan bool)
+	o.chStopped = make(chan bool)
+
+	//
+	// Start ticking.
+	//
+	go o.run()
+
+	ch := make(chan bool, 1)
+
+	ch <- true
+
+	return ch, nil
+}
+
+//
+// Stop implements the method defined by the services.Stop() interface.
+//
+func (o *WorldService) Stop() (<-chan bool, error) {
+	log.Printf("The World Service is stopping...")
+
+	//
+	// Kill the tick loop goroutine. We must wait for it to gracefully stop.
+	//
+	o.chKill <- true
+
+	<-o.chStopped
+
+	ch := make(chan bool, 1)
+
+	ch <- true
+
+	return ch, nil
+}
+
+//
+// QueueInput queues up a synchronization request received from the provided client so that it can
+// be applied during the next tick.
+//
+func (o *WorldService) QueueInput(client *models.Client, objSync *msgmodels.ObjSync) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.inputs = append(o.inputs, &input{
+		client:  client,
+		objSync: objSync,
+	})
+}
+
+//
+// Spawn adds the provided server-owned object to the game world so that it is simulated each tick
+// and so that clients in its area are told about it.
+//
+func (o *WorldService) Spawn(object Simulated) {
+	o.mu.Lock()
+	o.simulated[object.ObjectID()] = object
+	o.mu.Unlock()
+
+	gameserverservice.Instance().AddObject(object)
+}
+
+//
+// Despawn removes the server-owned object with the specified unique identifier from the game world.
+//
+func (o *WorldService) Despawn(objectID string) {
+	o.mu.Lock()
+	delete(o.simulated, objectID)
+	o.mu.Unlock()
+
+	gameserverservice.Instance().ForgetObject(objectID)
+}
+
+//
+// run loops at the configured tick rate, advancing the world simulation each time, until it is
+// told to stop. Intended to be run in its own goroutine.
+//
+func (o *WorldService) run() {
+	log.Printf("The world simulation has started ticking at %d Hz.", o.config.TickHz)
+
+	ticker := time.NewTicker(time.Second / time.Duration(o.config.TickHz))
+	defer ticker.Stop()
+
+	for cont := true; cont; {
+		select {
+		case <-o.chKill:
+			cont = false
+		case now := <-ticker.C:
+			o.tick(now)
+		}
+	}
+
+	log.Printf("The world simulation has stopped ticking.")
+
+	o.chStopped <- true
+}
+
+//
+// tick advances the world simulation by a single step. Queued player inputs are drained and
+// applied, server-owned objects are simulated, and the resulting changes are sent out to each area.
+//
+func (o *WorldService) tick(now time.Time) {
+	//
+	// Grab everything that we need to work on so that the lock is not held while sending.
+	//
+	o.mu.Lock()
+
+	inputs := o.inputs
+	o.inputs = make([]*input, 0, len(inputs))
+
+	simulated := make([]Simulated, 0, len(o.simulated))
+	for _, object := range o.simulated {
+		simulated = append(simulated, object)
+	}
+
+	dt := now.Sub(o.lastTick)
+	o.lastTick = now
+
+	o.mu.Unlock()
+
+	//
+	// Apply player inputs. Multiple inputs for the same object within a single tick are collapsed,
+	// with later values for the same variable winning.
+	//
+	deltas := make(map[string]*delta)
+
+	for _, in := range inputs {
+		if !gameserverservice.Instance().HasObject(in.objSync.ObjectID) {
+			continue
+		}
+
+		if in.objSync.ObjectID == in.client.ObjectID() {
+			in.client.ApplySyncVars(in.objSync.Variables)
+		}
+
+		d := deltaFor(deltas, in.objSync.ObjectID, in.client.AreaID())
+		d.owner = in.client
+
+		for key, val := range in.objSync.Variables {
+			d.variables[key] = val
+		}
+	}
+
+	//
+	// Advance all server-owned objects.
+	//
+	for _, object := range simulated {
+		vars := object.Tick(dt)
+		if len(vars) == 0 {
+			continue
+		}
+
+		d := deltaFor(deltas, object.ObjectID(), object.AreaID())
+
+		for key, val := range vars {
+			d.variables[key] = val
+		}
+	}
+
+	//
+	// Queue the accumulated changes up to be sent out to the clients in each object's area. The owner
+	// of an object is not told about its own changes – it already knows.
+	//
+	for objectID, d := range deltas {
+		objSync := &msgmodels.ObjSync{
+			ObjectID:  objectID,
+			AreaID:    d.areaID,
+			Variables: d.variables,
+		}
+
+		var excludedClientIDs []int
+
+		if d.owner != nil {
+			excludedClientIDs = []int{d.owner.ID()}
+		}
+
+		gameserverservice.Instance().QueueObjSync(objSync, excludedClientIDs)
+	}
+}
+
+//
+// deltaFor retrieves the accumulated changes for the specified object from the provided table,
+// creating a fresh entry if there is not one yet.
+//
+func deltaFor(deltas map[string]*delta, objectID string, areaID string) *delta {
+	d, prs := deltas[objectID]
+	if !prs {
+		d = &delta{
+			areaID:    areaID,
+			variables: make(map[string]interface{}),
+		}
+
+		deltas[objectID] = d
+	}
+
+	return d
+}
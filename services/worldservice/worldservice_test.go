@@ -0,0 +1,335 @@
+package worldservice_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lukehollenback/arcane-server/handlers"
+	"github.com/lukehollenback/arcane-server/models"
+	"github.com/lukehollenback/arcane-server/models/msgmodels"
+	"github.com/lukehollenback/arcane-server/services/authservice"
+	"github.com/lukehollenback/arcane-server/services/gameserverservice"
+	"github.com/lukehollenback/arcane-server/services/playerinfoservice"
+	"github.com/lukehollenback/arcane-server/services/worldservice"
+	"github.com/lukehollenback/arcane-server/transport"
+)
+
+//
+// quietPeriod is how long a test client waits to be sure that it is NOT going to be told about
+// something. It spans several object synchronization flushes.
+//
+const quietPeriod = 200 * time.Millisecond
+
+//
+// testClient represents an in-memory client connected to the game server.
+//
+type testClient struct {
+	pipe     *transport.PipeConn     // The client's end of its connection.
+	client   *models.Client          // The server's view of the client.
+	chServed chan bool               // Closed once the server has completely finished with the client.
+	chSyncs  chan *msgmodels.ObjSync // Every object synchronization that the client is sent.
+}
+
+//
+// npc is a server-owned object that counts its ticks, and synchronizes the count as its "x"
+// variable.
+//
+type npc struct {
+	dts []time.Duration // The amount of time that each of the object's ticks advanced it by.
+}
+
+func (o *npc) ObjectID() string { return "npc" }
+func (o *npc) Type() string     { return "oNPC" }
+func (o *npc) AreaID() string   { return models.DefaultAreaID }
+func (o *npc) X() int           { return len(o.dts) }
+func (o *npc) Y() int           { return 0 }
+func (o *npc) Depth() int       { return 0 }
+
+func (o *npc) Tick(dt time.Duration) map[string]interface{} {
+	o.dts = append(o.dts, dt)
+
+	return map[string]interface{}{models.SyncVarX: len(o.dts)}
+}
+
+func TestTickCollapsesInputs(t *testing.T) {
+	startServices(t)
+	defer stopServices(t)
+
+	alice := connect(t, 1)
+	defer alice.disconnect()
+
+	bob := connect(t, 2)
+	defer bob.disconnect()
+
+	objectID := alice.client.ObjectID()
+
+	worldservice.Instance().QueueInput(alice.client, &msgmodels.ObjSync{
+		ObjectID:  objectID,
+		Variables: map[string]interface{}{models.SyncVarX: 1, models.SyncVarY: 1},
+	})
+	worldservice.Instance().QueueInput(alice.client, &msgmodels.ObjSync{
+		ObjectID:  objectID,
+		Variables: map[string]interface{}{models.SyncVarX: 2},
+	})
+	worldservice.Instance().Tick(time.Now())
+
+	sync := bob.waitForSync(t, objectID)
+
+	if sync.Variables[models.SyncVarX] != 2.0 || sync.Variables[models.SyncVarY] != 1.0 {
+		t.Errorf("Expected the inputs to be collapsed with later values winning. (Variables: %v)", sync.Variables)
+	}
+
+	if extra := bob.nextSync(objectID, quietPeriod); extra != nil {
+		t.Errorf("Expected a single synchronization for the tick. (Extra: %v)", extra.Variables)
+	}
+
+	if alice.client.X() != 2 || alice.client.Y() != 1 {
+		t.Errorf("Expected the inputs to be applied to the client. (X: %d) (Y: %d)", alice.client.X(), alice.client.Y())
+	}
+}
+
+func TestTickExcludesOwnerFromFanOut(t *testing.T) {
+	startServices(t)
+	defer stopServices(t)
+
+	alice := connect(t, 1)
+	defer alice.disconnect()
+
+	bob := connect(t, 2)
+	defer bob.disconnect()
+
+	objectID := alice.client.ObjectID()
+
+	worldservice.Instance().QueueInput(alice.client, &msgmodels.ObjSync{
+		ObjectID:  objectID,
+		Variables: map[string]interface{}{models.SyncVarX: 7},
+	})
+	worldservice.Instance().Tick(time.Now())
+
+	if sync := bob.waitForSync(t, objectID); sync.Variables[models.SyncVarX] != 7.0 {
+		t.Errorf("Expected the other client to be told about the input. (Variables: %v)", sync.Variables)
+	}
+
+	if sync := alice.nextSync(objectID, quietPeriod); sync != nil {
+		t.Errorf("Expected the owner to not be told about its own input. (Variables: %v)", sync.Variables)
+	}
+}
+
+func TestTickQueuesSimulatedDeltas(t *testing.T) {
+	startServices(t)
+	defer stopServices(t)
+
+	alice := connect(t, 1)
+	defer alice.disconnect()
+
+	object := &npc{}
+
+	worldservice.Instance().Spawn(object)
+
+	now := time.Now()
+
+	worldservice.Instance().Tick(now)
+
+	if sync := alice.waitForSync(t, object.ObjectID()); sync.Variables[models.SyncVarX] != 1.0 {
+		t.Errorf("Expected the client to be told about the first tick. (Variables: %v)", sync.Variables)
+	}
+
+	worldservice.Instance().Tick(now.Add(50 * time.Millisecond))
+
+	if sync := alice.waitForSync(t, object.ObjectID()); sync.Variables[models.SyncVarX] != 2.0 {
+		t.Errorf("Expected the client to be told about the second tick. (Variables: %v)", sync.Variables)
+	}
+
+	if len(object.dts) != 2 || object.dts[1] != 50*time.Millisecond {
+		t.Errorf("Expected the object to be advanced by the time between ticks. (Advances: %v)", object.dts)
+	}
+
+	worldservice.Instance().Despawn(object.ObjectID())
+}
+
+//
+// startServices configures and starts every service that a client needs to be able to connect and
+// authenticate. The world service is started paused so that each test decides when it ticks.
+//
+func startServices(t *testing.T) {
+	t.Helper()
+
+	log.SetOutput(ioutil.Discard)
+
+	handlers.PkgInit()
+
+	tokenFile, err := ioutil.TempFile("", "tokens")
+	if err != nil {
+		t.Fatalf("Failed to create the token file. (Error: %s)", err)
+	}
+	defer os.Remove(tokenFile.Name())
+
+	fmt.Fprintln(tokenFile, "token1 player1 Alice")
+	fmt.Fprintln(tokenFile, "token2 player2 Bob")
+
+	tokenFile.Close()
+
+	verifier, err := authservice.CreateStaticTokenVerifier(tokenFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load the token file. (Error: %s)", err)
+	}
+
+	authservice.Instance().Config(&authservice.Config{Verifier: verifier, MaxFailures: 1})
+
+	playerinfoservice.Instance().Config(&playerinfoservice.Config{
+		Store:     playerinfoservice.CreateMemStore(),
+		CacheSize: 8,
+	})
+
+	gameserverservice.Instance().Config(&gameserverservice.Config{
+		TCPAddr:             "127.0.0.1:0",
+		SyncFlushIntervalMs: 10,
+	})
+
+	for _, start := range []func() (<-chan bool, error){
+		playerinfoservice.Instance().Start,
+		gameserverservice.Instance().Start,
+	} {
+		chStarted, err := start()
+		if err != nil {
+			t.Fatalf("Failed to start a service. (Error: %s)", err)
+		}
+
+		<-chStarted
+	}
+
+	worldservice.Instance().StartPaused()
+}
+
+//
+// stopServices stops every service started by startServices().
+//
+func stopServices(t *testing.T) {
+	t.Helper()
+
+	defer log.SetOutput(os.Stderr)
+
+	for _, stop := range []func() (<-chan bool, error){
+		gameserverservice.Instance().Stop,
+		playerinfoservice.Instance().Stop,
+	} {
+		chStopped, err := stop()
+		if err != nil {
+			t.Errorf("Failed to stop a service. (Error: %s)", err)
+
+			continue
+		}
+
+		<-chStopped
+	}
+}
+
+//
+// connect connects an in-memory client to the server and authenticates it as the player with the
+// specified number. Blocks until the server knows the client as that player.
+//
+func connect(t *testing.T, i int) *testClient {
+	t.Helper()
+
+	o := &testClient{
+		pipe:     transport.CreatePipeConn(i),
+		chServed: make(chan bool),
+		chSyncs:  make(chan *msgmodels.ObjSync, 64),
+	}
+
+	go func() {
+		gameserverservice.Instance().Serve(o.pipe)
+
+		close(o.chServed)
+	}()
+
+	go func() {
+		for raw := range o.pipe.Read() {
+			var msg struct {
+				Key  string
+				Data json.RawMessage
+			}
+
+			if err := json.Unmarshal(raw, &msg); err != nil || msg.Key != "ObjSyncBatch" {
+				continue
+			}
+
+			batch := new(msgmodels.ObjSyncBatch)
+
+			json.Unmarshal(msg.Data, batch)
+
+			for _, sync := range batch.Syncs {
+				select {
+				case o.chSyncs <- sync:
+				default:
+				}
+			}
+		}
+	}()
+
+	o.pipe.Write(`{"Key":"Hello","Data":{"ProtocolVersion":1}}`)
+	o.pipe.Write(fmt.Sprintf(`{"Key":"Auth","Data":{"Token":"token%d"}}`, i))
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); {
+		client, prs := gameserverservice.Instance().FindClientByPlayerID(fmt.Sprintf("player%d", i))
+		if prs {
+			o.client = client
+
+			return o
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Client %d never finished authenticating.", i)
+
+	return nil
+}
+
+//
+// disconnect closes the client's connection and blocks until the server has completely finished
+// with it.
+//
+func (o *testClient) disconnect() {
+	<-o.pipe.Close()
+	<-o.chServed
+}
+
+//
+// nextSync waits for up to the provided amount of time for the client to be sent a synchronization
+// of the object with the specified unique identifier. Returns nil if it is not sent one.
+//
+func (o *testClient) nextSync(objectID string, timeout time.Duration) *msgmodels.ObjSync {
+	chTimeout := time.After(timeout)
+
+	for {
+		select {
+		case sync := <-o.chSyncs:
+			if sync.ObjectID == objectID {
+				return sync
+			}
+		case <-chTimeout:
+			return nil
+		}
+	}
+}
+
+//
+// waitForSync is like nextSync(), but fails the test if the client is not sent a synchronization of
+// the object with the specified unique identifier in good time.
+//
+func (o *testClient) waitForSync(t *testing.T, objectID string) *msgmodels.ObjSync {
+	t.Helper()
+
+	sync := o.nextSync(objectID, 2*time.Second)
+	if sync == nil {
+		t.Fatalf("Expected a synchronization of object %s.", objectID)
+	}
+
+	return sync
+}
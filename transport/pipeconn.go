@@ -0,0 +1,192 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	//
+	// pipeBufferSize is the number of messages that may be queued in each direction of a pipe before
+	// further messages are refused.
+	//
+	pipeBufferSize = 256
+)
+
+var (
+	//
+	// ErrPipeClosed is returned when attempting to use a pipe that has been closed.
+	//
+	ErrPipeClosed = errors.New("the pipe has been closed")
+
+	//
+	// ErrPipeFull is returned when a message is sent over a pipe whose remote end has not kept up
+	// with reading.
+	//
+	ErrPipeFull = errors.New("the pipe is full")
+)
+
+//
+// PipeConn is an in-memory implementation of the models.Connection interface. The server side uses
+// it like any other connection, while the remote side (e.g. a unit test standing in for a game
+// client) uses Write() and Read() to exchange messages with it. No sockets are involved.
+//
+type PipeConn struct {
+	id        int         // The unique connection identifier that has been assigned to the pipe.
+	mu        *sync.Mutex // Mutex to protect against sending over the pipe while it is being closed.
+	closed    bool        // Whether or not the pipe has been completely closed.
+	closeOnce *sync.Once  // Ensures that the pipe is only ever asked to close once.
+	chIn      chan string // Channel of messages written by the remote side that have not been read by the server yet.
+	chOut     chan []byte // Channel of messages sent by the server that have not been read by the remote side yet.
+	chClose   chan bool   // Channel that is closed once the pipe has been asked to close.
+	chDone    chan bool   // Channel that is closed once the pipe has been completely closed.
+}
+
+//
+// CreatePipeConn constructs a new in-memory connection with the specified unique connection
+// identifier and returns a pointer to it. It is up to the caller to ensure that the identifier does
+// not collide with that of any other open connection.
+//
+func CreatePipeConn(id int) *PipeConn {
+	return &PipeConn{
+		id:        id,
+		mu:        &sync.Mutex{},
+		closeOnce: &sync.Once{},
+		chIn:      make(chan string, pipeBufferSize),
+		chOut:     make(chan []byte, pipeBufferSize),
+		chClose:   make(chan bool),
+		chDone:    make(chan bool),
+	}
+}
+
+//
+// String returns a printable representation of the pipe.
+//
+func (o *PipeConn) String() string {
+	return fmt.Sprintf("%05d MEM %21s", o.id, o.RemoteAddr())
+}
+
+//
+// Write sends the specified message from the remote side of the pipe to the server side.
+//
+func (o *PipeConn) Write(msg string) error {
+	select {
+	case <-o.chClose:
+		return ErrPipeClosed
+	case o.chIn <- msg:
+		return nil
+	}
+}
+
+//
+// Read returns a channel over which the remote side of the pipe receives the messages sent by the
+// server side. The channel is closed once the pipe has been completely closed.
+//
+func (o *PipeConn) Read() <-chan []byte {
+	return o.chOut
+}
+
+//
+// Implementation of models.Connection.ID().
+//
+func (o *PipeConn) ID() int {
+	return o.id
+}
+
+//
+// Implementation of models.Connection.LogPrefix().
+//
+func (o *PipeConn) LogPrefix() string {
+	return o.logPrefix("  ")
+}
+
+//
+// Implementation of models.Connection.RcvLogPrefix().
+//
+func (o *PipeConn) RcvLogPrefix() string {
+	return o.logPrefix("~>")
+}
+
+//
+// Implementation of models.Connection.SndLogPrefix().
+//
+func (o *PipeConn) SndLogPrefix() string {
+	return o.logPrefix("<~")
+}
+
+//
+// Implementation of models.Connection.RemoteAddr().
+//
+func (o *PipeConn) RemoteAddr() string {
+	return "pipe"
+}
+
+//
+// Implementation of models.Connection.Close(). The returned channel is signaled once Listen() has
+// returned.
+//
+func (o *PipeConn) Close() <-chan bool {
+	o.closeOnce.Do(func() {
+		close(o.chClose)
+	})
+
+	return o.chDone
+}
+
+//
+// Implementation of models.Connection.Send(). Rather than blocking on a remote side that is not
+// reading, an error is returned once the pipe's buffer has filled up.
+//
+func (o *PipeConn) Send(b []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return ErrPipeClosed
+	}
+
+	msg := make([]byte, len(b))
+
+	copy(msg, b)
+
+	select {
+	case o.chOut <- msg:
+		return nil
+	default:
+		return ErrPipeFull
+	}
+}
+
+//
+// Listen passes each message written by the remote side of the pipe to the provided callback until
+// the pipe is closed. It blocks, so it is intended to be run in the goroutine that owns the pipe.
+//
+func (o *PipeConn) Listen(onMessage func(msg string)) {
+	for cont := true; cont; {
+		select {
+		case <-o.chClose:
+			cont = false
+		case msg := <-o.chIn:
+			onMessage(msg)
+		}
+	}
+
+	o.mu.Lock()
+
+	o.closed = true
+	close(o.chOut)
+
+	o.mu.Unlock()
+
+	close(o.chDone)
+}
+
+//
+// logPrefix actually generates the prefix strings returned by the various "*LogPrefix()" methods.
+// It mirrors the format used by the TCP/IP packet server so that log lines from all transports line
+// up.
+//
+func (o *PipeConn) logPrefix(symbol string) string {
+	return fmt.Sprintf("<~> %s %s ", o.String(), symbol)
+}
@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"github.com/lukehollenback/packet-server/tcp"
+)
+
+//
+// TCPConn adapts a TCP/IP packet server client to the models.Connection interface.
+//
+type TCPConn struct {
+	tcpClient *tcp.Client // The actual TCP/IP packet server client instance being adapted.
+}
+
+//
+// CreateTCPConn wraps the provided TCP/IP packet server client in a new connection adapter and
+// returns a pointer to it.
+//
+func CreateTCPConn(tcpClient *tcp.Client) *TCPConn {
+	return &TCPConn{
+		tcpClient: tcpClient,
+	}
+}
+
+//
+// Implementation of models.Connection.ID().
+//
+func (o *TCPConn) ID() int {
+	return o.tcpClient.ID()
+}
+
+//
+// Implementation of models.Connection.LogPrefix().
+//
+func (o *TCPConn) LogPrefix() string {
+	return o.tcpClient.LogPrefix()
+}
+
+//
+// Implementation of models.Connection.RcvLogPrefix().
+//
+func (o *TCPConn) RcvLogPrefix() string {
+	return o.tcpClient.RcvLogPrefix()
+}
+
+//
+// Implementation of models.Connection.SndLogPrefix().
+//
+func (o *TCPConn) SndLogPrefix() string {
+	return o.tcpClient.SndLogPrefix()
+}
+
+//
+// Implementation of models.Connection.RemoteAddr().
+//
+func (o *TCPConn) RemoteAddr() string {
+	return o.tcpClient.RemoteAddr()
+}
+
+//
+// Implementation of models.Connection.Close().
+//
+func (o *TCPConn) Close() <-chan bool {
+	return o.tcpClient.Close()
+}
+
+//
+// Implementation of models.Connection.Send(). The packet server appends the NUL delimiter that
+// separates messages on the stream.
+//
+func (o *TCPConn) Send(b []byte) error {
+	return o.tcpClient.SendBytes(b)
+}
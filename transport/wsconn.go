@@ -0,0 +1,160 @@
+package transport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	//
+	// WSMaxMsgBytes is the largest message that will be read from a WebSocket connection. Anything
+	// larger causes the connection to be closed.
+	//
+	WSMaxMsgBytes = 64 * 1024
+
+	//
+	// wsWriteTimeout is how long a write to a WebSocket connection may block before it is abandoned.
+	//
+	wsWriteTimeout = 10 * time.Second
+)
+
+//
+// WSConn adapts a WebSocket connection to the models.Connection interface.
+//
+type WSConn struct {
+	id        int             // The unique connection identifier that has been assigned to the connection.
+	conn      *websocket.Conn // The actual WebSocket connection.
+	writeMu   *sync.Mutex     // Mutex to prevent concurrent writes, which WebSocket connections do not support.
+	closeOnce *sync.Once      // Ensures that the underlying connection is only ever closed once.
+	chDone    chan bool       // Channel that is closed once the connection has been completely closed.
+}
+
+//
+// CreateWSConn wraps the provided (already upgraded) WebSocket connection in a new connection
+// adapter with the specified unique connection identifier and returns a pointer to it.
+//
+func CreateWSConn(id int, conn *websocket.Conn) *WSConn {
+	conn.SetReadLimit(WSMaxMsgBytes)
+
+	return &WSConn{
+		id:        id,
+		conn:      conn,
+		writeMu:   &sync.Mutex{},
+		closeOnce: &sync.Once{},
+		chDone:    make(chan bool),
+	}
+}
+
+//
+// String returns a printable representation of the connection.
+//
+func (o *WSConn) String() string {
+	return fmt.Sprintf("%05d WS  %21s", o.id, o.RemoteAddr())
+}
+
+//
+// Implementation of models.Connection.ID().
+//
+func (o *WSConn) ID() int {
+	return o.id
+}
+
+//
+// Implementation of models.Connection.LogPrefix().
+//
+func (o *WSConn) LogPrefix() string {
+	return o.logPrefix("  ")
+}
+
+//
+// Implementation of models.Connection.RcvLogPrefix().
+//
+func (o *WSConn) RcvLogPrefix() string {
+	return o.logPrefix("~>")
+}
+
+//
+// Implementation of models.Connection.SndLogPrefix().
+//
+func (o *WSConn) SndLogPrefix() string {
+	return o.logPrefix("<~")
+}
+
+//
+// Implementation of models.Connection.RemoteAddr().
+//
+func (o *WSConn) RemoteAddr() string {
+	return o.conn.RemoteAddr().String()
+}
+
+//
+// Implementation of models.Connection.Close(). The returned channel is signaled once Listen() has
+// returned.
+//
+func (o *WSConn) Close() <-chan bool {
+	o.closeOnce.Do(func() {
+		o.writeMu.Lock()
+		defer o.writeMu.Unlock()
+
+		o.conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(wsWriteTimeout),
+		)
+		o.conn.Close()
+	})
+
+	return o.chDone
+}
+
+//
+// Implementation of models.Connection.Send(). Each message is sent as its own WebSocket message, so
+// no delimiter is needed. Messages that are valid UTF-8 (e.g. JSON) are sent as text messages, and
+// everything else (e.g. the binary codec) is sent as binary messages.
+//
+func (o *WSConn) Send(b []byte) error {
+	msgType := websocket.BinaryMessage
+	if utf8.Valid(b) {
+		msgType = websocket.TextMessage
+	}
+
+	o.writeMu.Lock()
+	defer o.writeMu.Unlock()
+
+	o.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+
+	return o.conn.WriteMessage(msgType, b)
+}
+
+//
+// Listen reads messages from the connection and passes each one to the provided callback until the
+// connection is closed (by either side). It blocks, so it is intended to be run in the goroutine
+// that owns the connection.
+//
+func (o *WSConn) Listen(onMessage func(msg string)) {
+	for {
+		_, msg, err := o.conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		onMessage(string(msg))
+	}
+
+	o.Close()
+
+	close(o.chDone)
+}
+
+//
+// logPrefix actually generates the prefix strings returned by the various "*LogPrefix()" methods.
+// It mirrors the format used by the TCP/IP packet server so that log lines from both transports
+// line up.
+//
+func (o *WSConn) logPrefix(symbol string) string {
+	return fmt.Sprintf("<~> %s %s ", o.String(), symbol)
+}
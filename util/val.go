@@ -11,3 +11,38 @@ func GetStrVal(val string, def string) string {
 
 	return val
 }
+
+//
+// GetIntVal attempts to interpret the provided value (e.g. a number that was deserialized from
+// JSON as a float) as an integer. Returns false if the value is not numeric.
+//
+func GetIntVal(val interface{}) (int, bool) {
+	switch v := val.(type) {
+	case int:
+		return v, true
+	case int8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case uint:
+		return int(v), true
+	case uint8:
+		return int(v), true
+	case uint16:
+		return int(v), true
+	case uint32:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float32:
+		return int(v), true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}